@@ -80,6 +80,15 @@ Options:
  -P, --pid PID			backend PID to profile to
  -F, --freq FREQ		profile at this frequency (default: 100ms, min: 1ms, max: 1s)
  -s, --strsize SIZE		limit length of print query strings to STRSIZE chars (default 128)
+     --format FORMAT		output format: text, json, folded (default: text)
+ -o, --output FILE		write profile to file instead of stdout
+
+Filter options (profile all matching backends instead of single PID, stop with Ctrl+C):
+     --filter-database DBNAME	profile backends connected to the database
+     --filter-user USERNAME	profile backends of the user
+     --filter-application NAME	profile backends with the application_name
+     --filter-query REGEXP	profile backends executing queries matching the regexp
+     --filter-queryid ID	profile backends executing queries with the query_id (Postgres 14 and newer)
 
 General options:
  -?, --help		show this help and exit
@@ -102,6 +111,11 @@ Options:
   -p, --port PORT		database server port (default 5432)
   -U, --username USERNAME	database user name
 
+      --from-file FILE		replay stats recorded by 'pgcenter record' instead of connecting to Postgres
+      --history INT		number of stats snapshots kept in history (default: 300)
+      --profile NAME		name of the settings profile (default: default), profiles are saved in $HOME/.config/pgcenter/top.yaml
+      --views-file FILE		file with user-defined views (default: $HOME/.config/pgcenter/views.yaml)
+
 General options:
   -?, --help		show this help and exit
 
@@ -125,10 +139,22 @@ Options:
 
  -i, --interval DURATION	statistics recording interval (default: 1s)
  -c, --count INT		number of statistics samples to record
- -f, --file FILENAME		file name where statistics to write to (default: pgcenter.stat.tar)
+ -f, --file FILENAME		file name where statistics to write to (default: pgcenter.stat.tar or pgcenter.stat.tar.gz)
  -a, --append			append statistics to file (defailt: true)
  -s, --strlimit INT		maximum query length to record (default: 0, no limit)
  -1, --oneshot			append single statistics snapshot and exit (alias for --interval 0 --count 1)
+     --daemon			keep recording on errors: reconnect to Postgres, log errors and mark gaps
+     --log-file FILE		file where errors are logged in daemon mode, reopened on SIGHUP (default: stderr)
+ -z, --compress			write compressed stats, only changed rows are written between full snapshots
+     --keyframe-interval INT	number of snapshots between full snapshots in compressed archive (default: 60)
+     --max-size SIZE		start a new archive when current one exceeds size, e.g. 100MB
+     --rotate-interval DURATION	start a new archive after interval, e.g. 1h
+     --keep INT			number of rotated archives to keep (default: 0, keep all)
+     --views LIST		comma-separated list of views to record (default: all views)
+     --view-interval LIST	recording intervals of particular views, e.g. sizes=5m,activity=1s
+     --ash			sample active sessions instead of recording stats, samples are written every recording interval
+     --ash-interval DURATION	interval of active sessions sampling (default: 100ms)
+     --views-file FILE		file with user-defined views (default: $HOME/.config/pgcenter/views.yaml)
 
 General options:
  -?, --help		show this help and exit
@@ -146,7 +172,7 @@ Usage:
  pgcenter report [OPTIONS]...
 
 Options:
- -f, --file FILE		read stats from file (default: pgcenter.stat.tar)
+ -f, --file FILE		read stats from file, directory or glob pattern of files (default: pgcenter.stat.tar)
  -s, --start TIMESTAMP		starting time of the report (format: [YYYY-MM-DD] HH:MM:SS)
  -e, --end TIMESTAMP		ending time of the report (format: [YYYY-MM-DD] HH:MM:SS)
  -o, --order COLNAME		order values by column
@@ -154,8 +180,14 @@ Options:
      --asc			use ascendant order
  -g, --grep COLNAME:PATTERN	filter values in specfied column (format: colname:filtertext)
  -l, --limit INT		print only limited number of rows per sample (default: unlimited)
+     --compare FILE		compare average rates with stats from another file (or another interval of the same file)
+     --compare-start TIMESTAMP	starting time of the compared stats (format: [YYYY-MM-DD] HH:MM:SS)
+     --compare-end TIMESTAMP	ending time of the compared stats (format: [YYYY-MM-DD] HH:MM:SS)
+     --summary			print totals, avg, min, max and p95 of values aggregated over the whole report interval
  -t, --strlimit INT		maximum string size to print (default: 32, 0 disables)
  -r, --rate DURATION		statistics changes rate interval (default: 1s)
+     --format FORMAT		output format: text, csv, json, jsonl, markdown (default: text)
+     --views-file FILE		file with user-defined views (default: $HOME/.config/pgcenter/views.yaml)
 
 Report options:
  -A, --activity			show pg_stat_activity statistics
@@ -170,6 +202,12 @@ Report options:
 				'm' - timings; 'g' - general; 'i' - io; 't' - temp files io; 'l' - local files io
  -P, --progress SELECTOR	show pg_stat_progress_* statistics, use additional selector to choose stats
 				'v' - vacuum; 'c' - cluster; 'i' - create index
+     --custom NAME		show statistics of user-defined view
+     --sysstat			show load average, CPU and memory usage statistics
+     --diskstats		show block devices statistics
+     --netdev			show network interfaces statistics
+     --ash			show database time broken down by wait events, queries, users and databases
+				using samples of active sessions, recorded with 'pgcenter record --ash'
 
  -d, --describe			show statistics description, combined with one of the report options
 
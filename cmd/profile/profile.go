@@ -7,12 +7,14 @@ import (
 	"github.com/lesovsky/pgcenter/internal/postgres"
 	"github.com/lesovsky/pgcenter/profile"
 	"github.com/spf13/cobra"
+	"regexp"
 	"time"
 )
 
 var (
 	profileConfig profile.Config
 	connOptions   postgres.ConnectionOptions
+	queryFilter   string // regexp for filtering profiled backends by queries
 
 	// CommandDefinition is the definition of 'profile' CLI sub-command
 	CommandDefinition = &cobra.Command{
@@ -31,6 +33,15 @@ var (
 				return err
 			}
 
+			// Compile regexp if specified.
+			if queryFilter != "" {
+				re, err := regexp.Compile(queryFilter)
+				if err != nil {
+					return err
+				}
+				profileConfig.Filter.Query = re
+			}
+
 			err = validate(profileConfig)
 			if err != nil {
 				return err
@@ -49,13 +60,32 @@ func init() {
 	CommandDefinition.Flags().IntVarP(&profileConfig.Pid, "pid", "P", 0, "PID of Postgres backend to profile to")
 	CommandDefinition.Flags().DurationVarP(&profileConfig.Frequency, "freq", "F", 100*time.Millisecond, "profile with this frequency (default: 100ms)")
 	CommandDefinition.Flags().IntVarP(&profileConfig.Strsize, "strsize", "s", 128, "limit length of print query strings to STRSIZE chars (default 128)")
-
-	_ = CommandDefinition.MarkFlagRequired("pid")
+	CommandDefinition.Flags().StringVarP(&profileConfig.Format, "format", "", profile.FormatText, "output format: text, json, folded")
+	CommandDefinition.Flags().StringVarP(&profileConfig.Output, "output", "o", "", "write profile to file instead of stdout")
+	CommandDefinition.Flags().StringVarP(&profileConfig.Filter.Database, "filter-database", "", "", "profile backends connected to the database")
+	CommandDefinition.Flags().StringVarP(&profileConfig.Filter.User, "filter-user", "", "", "profile backends of the user")
+	CommandDefinition.Flags().StringVarP(&profileConfig.Filter.AppName, "filter-application", "", "", "profile backends with the application_name")
+	CommandDefinition.Flags().StringVarP(&queryFilter, "filter-query", "", "", "profile backends executing queries matching the regexp")
+	CommandDefinition.Flags().StringVarP(&profileConfig.Filter.QueryID, "filter-queryid", "", "", "profile backends executing queries with the query_id (Postgres 14 and newer)")
 }
 
 func validate(config profile.Config) error {
 	if config.Frequency < time.Millisecond || config.Frequency > time.Second {
 		return fmt.Errorf("invalid profile frequency, must be between 1 millisecond and 1 second")
 	}
+
+	switch config.Format {
+	case profile.FormatText, profile.FormatJSON, profile.FormatFolded:
+	default:
+		return fmt.Errorf("unknown output format: %s", config.Format)
+	}
+
+	// Single backend is profiled by PID, or many backends are profiled by filter.
+	if config.Pid == 0 && config.Filter.IsEmpty() {
+		return fmt.Errorf("PID or filter of profiled backends must be specified")
+	}
+	if config.Pid != 0 && !config.Filter.IsEmpty() {
+		return fmt.Errorf("PID and filter of profiled backends can't be used together")
+	}
 	return nil
 }
@@ -12,9 +12,15 @@ func Test_validate(t *testing.T) {
 		valid bool
 		cfg   profile.Config
 	}{
-		{valid: true, cfg: profile.Config{Frequency: 50 * time.Millisecond}},
-		{valid: false, cfg: profile.Config{Frequency: time.Millisecond - 1}},
-		{valid: false, cfg: profile.Config{Frequency: time.Second + 1}},
+		{valid: true, cfg: profile.Config{Pid: 123, Frequency: 50 * time.Millisecond, Format: "text"}},
+		{valid: true, cfg: profile.Config{Frequency: 50 * time.Millisecond, Format: "text", Filter: profile.Filter{Database: "shop"}}},
+		{valid: true, cfg: profile.Config{Pid: 123, Frequency: 50 * time.Millisecond, Format: "json"}},
+		{valid: true, cfg: profile.Config{Pid: 123, Frequency: 50 * time.Millisecond, Format: "folded"}},
+		{valid: false, cfg: profile.Config{Pid: 123, Frequency: 50 * time.Millisecond, Format: "xml"}},
+		{valid: false, cfg: profile.Config{Pid: 123, Frequency: time.Millisecond - 1, Format: "text"}},
+		{valid: false, cfg: profile.Config{Pid: 123, Frequency: time.Second + 1, Format: "text"}},
+		{valid: false, cfg: profile.Config{Frequency: 50 * time.Millisecond, Format: "text"}},
+		{valid: false, cfg: profile.Config{Pid: 123, Frequency: 50 * time.Millisecond, Format: "text", Filter: profile.Filter{User: "alice"}}},
 	}
 
 	for _, tc := range testcases {
@@ -3,16 +3,26 @@
 package record
 
 import (
+	"fmt"
 	"github.com/lesovsky/pgcenter/internal/postgres"
 	"github.com/lesovsky/pgcenter/record"
 	"github.com/spf13/cobra"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	defaultRecordFile           = "pgcenter.stat.tar"
+	defaultCompressedRecordFile = "pgcenter.stat.tar.gz"
+)
+
 var (
-	recordConfig record.Config
-	connOptions  postgres.ConnectionOptions
-	oneshot      bool
+	recordConfig  record.Config
+	connOptions   postgres.ConnectionOptions
+	oneshot       bool
+	maxSize       string
+	viewIntervals []string
 
 	// CommandDefinition defines 'record' sub-command.
 	CommandDefinition = &cobra.Command{
@@ -27,11 +37,50 @@ var (
 				recordConfig.Interval = time.Millisecond // interval must not be zero - ticker will panic.
 			}
 
+			// Compressed archives have their own default name.
+			if recordConfig.Compress && !command.Flags().Changed("file") {
+				recordConfig.OutputFile = defaultCompressedRecordFile
+			}
+
+			// Parse archive size limit.
+			if maxSize != "" {
+				size, err := parseSize(maxSize)
+				if err != nil {
+					return err
+				}
+				recordConfig.MaxSize = size
+			}
+
+			if recordConfig.Keep < 0 {
+				return fmt.Errorf("number of archives to keep must not be negative")
+			}
+
+			// Parse per-view recording intervals.
+			intervals, err := parseViewIntervals(viewIntervals)
+			if err != nil {
+				return err
+			}
+			recordConfig.ViewIntervals = intervals
+
+			// Sampling of active sessions replaces recording of views.
+			if recordConfig.ASH {
+				if len(recordConfig.Views) > 0 || len(recordConfig.ViewIntervals) > 0 {
+					return fmt.Errorf("--ash can't be used together with --views or --view-interval")
+				}
+
+				if recordConfig.ASHInterval <= 0 || recordConfig.ASHInterval > recordConfig.Interval {
+					return fmt.Errorf("sampling interval must be positive and not longer than recording interval")
+				}
+			}
+
 			// Parse extra arguments.
 			if len(args) > 0 {
 				connOptions.ParseExtraArgs(args)
 			}
 
+			// Version of pgcenter is written into recorded stats.
+			recordConfig.Version = strings.TrimSpace(command.Root().Version)
+
 			// Create connection config.
 			pgConfig, err := postgres.NewConfig(connOptions.Host, connOptions.Port, connOptions.User, connOptions.Dbname)
 			if err != nil {
@@ -44,16 +93,71 @@ var (
 )
 
 func init() {
-	defaultRecordFile := "pgcenter.stat.tar"
-
 	CommandDefinition.Flags().StringVarP(&connOptions.Host, "host", "h", "", "database server host or socket directory")
 	CommandDefinition.Flags().IntVarP(&connOptions.Port, "port", "p", 5432, "database server port")
 	CommandDefinition.Flags().StringVarP(&connOptions.User, "username", "U", "", "database user name")
 	CommandDefinition.Flags().StringVarP(&connOptions.Dbname, "dbname", "d", "", "database name to connect to")
 	CommandDefinition.Flags().DurationVarP(&recordConfig.Interval, "interval", "i", time.Second, "statistics recording interval (default: 1 second)")
-	CommandDefinition.Flags().IntVarP(&recordConfig.Count, "count", "c", -1, "number of statistics samples to record")
+	CommandDefinition.Flags().IntVarP(&recordConfig.Count, "count", "c", -1, "number of statistics samples to record (with --ash, number of recording intervals)")
 	CommandDefinition.Flags().StringVarP(&recordConfig.OutputFile, "file", "f", defaultRecordFile, "file where statistics are saved")
 	CommandDefinition.Flags().BoolVarP(&recordConfig.AppendFile, "append", "a", false, "append statistics to file (default: true)")
 	CommandDefinition.Flags().IntVarP(&recordConfig.StringLimit, "strlimit", "t", 0, "maximum query length to record (default: 0, no limit)")
 	CommandDefinition.Flags().BoolVarP(&oneshot, "oneshot", "1", false, "append single statistics snapshot to file and exit")
+	CommandDefinition.Flags().BoolVarP(&recordConfig.Daemon, "daemon", "", false, "keep recording on errors: reconnect to Postgres, log errors and mark gaps")
+	CommandDefinition.Flags().StringVarP(&recordConfig.LogFile, "log-file", "", "", "file where errors are logged in daemon mode (default: stderr)")
+	CommandDefinition.Flags().BoolVarP(&recordConfig.Compress, "compress", "z", false, "write compressed stats, only changed rows are written between full snapshots")
+	CommandDefinition.Flags().IntVarP(&recordConfig.KeyframeInterval, "keyframe-interval", "", 60, "number of snapshots between full snapshots in compressed archive")
+	CommandDefinition.Flags().StringVarP(&maxSize, "max-size", "", "", "start a new archive when current one exceeds size, e.g. 100MB")
+	CommandDefinition.Flags().DurationVarP(&recordConfig.RotateInterval, "rotate-interval", "", 0, "start a new archive after interval, e.g. 1h")
+	CommandDefinition.Flags().IntVarP(&recordConfig.Keep, "keep", "", 0, "number of rotated archives to keep (default: 0, keep all)")
+	CommandDefinition.Flags().StringSliceVarP(&recordConfig.Views, "views", "", nil, "comma-separated list of views to record (default: all views)")
+	CommandDefinition.Flags().StringSliceVarP(&viewIntervals, "view-interval", "", nil, "recording intervals of particular views, e.g. sizes=5m,activity=1s")
+	CommandDefinition.Flags().BoolVarP(&recordConfig.ASH, "ash", "", false, "sample active sessions instead of recording stats (active session history)")
+	CommandDefinition.Flags().DurationVarP(&recordConfig.ASHInterval, "ash-interval", "", 100*time.Millisecond, "interval of active sessions sampling")
+	CommandDefinition.Flags().StringVarP(&recordConfig.ViewsFile, "views-file", "", "", "file with user-defined views (default: $HOME/.config/pgcenter/views.yaml)")
+}
+
+// parseSize parses size string with optional unit suffix (B, KB, MB, GB, TB) and returns size in bytes.
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+
+	v, factor := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(v, u.suffix) {
+			v, factor = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+
+	return n * factor, nil
+}
+
+// parseViewIntervals parses recording intervals of views specified as 'view=interval' pairs.
+func parseViewIntervals(list []string) (map[string]time.Duration, error) {
+	intervals := make(map[string]time.Duration, len(list))
+	for _, item := range list {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid view interval: %s, must be in format 'view=interval'", item)
+		}
+
+		d, err := time.ParseDuration(parts[1])
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid interval for view %s: %s", parts[0], parts[1])
+		}
+		intervals[parts[0]] = d
+	}
+
+	return intervals, nil
 }
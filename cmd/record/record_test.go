@@ -0,0 +1,53 @@
+package record
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_parseSize(t *testing.T) {
+	testcases := []struct {
+		valid bool
+		size  string
+		want  int64
+	}{
+		{valid: true, size: "1024", want: 1024},
+		{valid: true, size: "100B", want: 100},
+		{valid: true, size: "10K", want: 10 * 1024},
+		{valid: true, size: "10kb", want: 10 * 1024},
+		{valid: true, size: "100MB", want: 100 * 1024 * 1024},
+		{valid: true, size: "2 GB", want: 2 * 1024 * 1024 * 1024},
+		{valid: true, size: "1T", want: 1024 * 1024 * 1024 * 1024},
+		{valid: false, size: ""},
+		{valid: false, size: "MB"},
+		{valid: false, size: "-1MB"},
+		{valid: false, size: "1.5GB"},
+		{valid: false, size: "10XB"},
+	}
+
+	for _, tc := range testcases {
+		got, err := parseSize(tc.size)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func Test_parseViewIntervals(t *testing.T) {
+	got, err := parseViewIntervals([]string{"sizes=5m", "activity=1s"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"sizes": 5 * time.Minute, "activity": time.Second}, got)
+
+	got, err = parseViewIntervals(nil)
+	assert.NoError(t, err)
+	assert.Len(t, got, 0)
+
+	for _, s := range []string{"sizes", "=5m", "sizes=", "sizes=5", "sizes=0s", "sizes=-1m"} {
+		_, err := parseViewIntervals([]string{s})
+		assert.Error(t, err)
+	}
+}
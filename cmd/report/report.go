@@ -24,6 +24,11 @@ type options struct {
 	showFunctions   bool   // Show stats from pg_stat_user_functions
 	showStatements  string // Show stats from pg_stat_statements
 	showProgress    string // Show stats from pg_stat_progress_* stats
+	showCustom      string // Show stats from user-defined view
+	showSysstat     bool   // Show load average, CPU and memory usage stats
+	showDiskstats   bool   // Show block devices stats
+	showNetdev      bool   // Show network interfaces stats
+	showASH         bool   // Show database time using samples of active sessions
 
 	inputFile      string        // Input file with statistics
 	tsStart, tsEnd string        // Show stats within an interval
@@ -34,6 +39,12 @@ type options struct {
 	rowLimit       int           // Number of rows per timestamp
 	strLimit       int           // Trim all strings longer than this limit
 	rate           time.Duration // Stats rate
+	viewsFile      string        // File with user-defined views
+	format         string        // Output format
+	summary        bool          // Print aggregated stats over the whole report interval
+	compareFile    string        // File with statistics to compare with
+	compareStart   string        // Start of the compared interval
+	compareEnd     string        // End of the compared interval
 }
 
 var (
@@ -66,8 +77,13 @@ func init() {
 	CommandDefinition.Flags().BoolVarP(&opts.showFunctions, "functions", "F", false, "show pg_stat_user_functions report")
 	CommandDefinition.Flags().StringVarP(&opts.showStatements, "statements", "X", "", "show pg_stat_statements report")
 	CommandDefinition.Flags().StringVarP(&opts.showProgress, "progress", "P", "", "show pg_stat_progress_* report")
+	CommandDefinition.Flags().StringVarP(&opts.showCustom, "custom", "", "", "show report for user-defined view")
+	CommandDefinition.Flags().BoolVarP(&opts.showSysstat, "sysstat", "", false, "show load average, CPU and memory usage report")
+	CommandDefinition.Flags().BoolVarP(&opts.showDiskstats, "diskstats", "", false, "show block devices report")
+	CommandDefinition.Flags().BoolVarP(&opts.showNetdev, "netdev", "", false, "show network interfaces report")
+	CommandDefinition.Flags().BoolVarP(&opts.showASH, "ash", "", false, "show active session history report")
 
-	CommandDefinition.Flags().StringVarP(&opts.inputFile, "file", "f", "pgcenter.stat.tar", "read stats from file")
+	CommandDefinition.Flags().StringVarP(&opts.inputFile, "file", "f", "pgcenter.stat.tar", "read stats from file, directory or glob pattern of files")
 	CommandDefinition.Flags().StringVarP(&opts.tsStart, "start", "s", "", "starting time of the report")
 	CommandDefinition.Flags().StringVarP(&opts.tsEnd, "end", "e", "", "ending time of the report")
 	CommandDefinition.Flags().StringVarP(&opts.orderColName, "order", "o", "", "sort values by column using descendant order")
@@ -77,6 +93,12 @@ func init() {
 	CommandDefinition.Flags().IntVarP(&opts.rowLimit, "limit", "l", 0, "print only limited number of rows per sample")
 	CommandDefinition.Flags().IntVarP(&opts.strLimit, "strlimit", "t", 32, "maximum string size for long lines to print (default: 32)")
 	CommandDefinition.Flags().DurationVarP(&opts.rate, "rate", "r", time.Second, "statistics changes rate interval (default: 1s)")
+	CommandDefinition.Flags().BoolVarP(&opts.summary, "summary", "", false, "print stats aggregated over the whole report interval")
+	CommandDefinition.Flags().StringVarP(&opts.compareFile, "compare", "", "", "compare with stats from specified file")
+	CommandDefinition.Flags().StringVarP(&opts.compareStart, "compare-start", "", "", "starting time of the compared stats")
+	CommandDefinition.Flags().StringVarP(&opts.compareEnd, "compare-end", "", "", "ending time of the compared stats")
+	CommandDefinition.Flags().StringVarP(&opts.format, "format", "", "text", "output format: text, csv, json, jsonl, markdown")
+	CommandDefinition.Flags().StringVarP(&opts.viewsFile, "views-file", "", "", "file with user-defined views (default: $HOME/.config/pgcenter/views.yaml)")
 }
 
 // validate parses and validates options passed by user and returns options ready for 'pgcenter report'.
@@ -98,6 +120,20 @@ func (opts options) validate() (report.Config, error) {
 		return report.Config{}, err
 	}
 
+	// Define compared interval.
+	if opts.summary && opts.compareFile != "" {
+		return report.Config{}, fmt.Errorf("summary and compare can't be used together")
+	}
+
+	if r == "ash" && (opts.summary || opts.compareFile != "") {
+		return report.Config{}, fmt.Errorf("summary and compare are not supported for active session history report")
+	}
+
+	cmpStart, cmpEnd, err := setReportInterval(opts.compareStart, opts.compareEnd)
+	if err != nil {
+		return report.Config{}, err
+	}
+
 	// Compile regexp if specified.
 	colname, re, err := parseFilterString(opts.filter)
 	if err != nil {
@@ -123,6 +159,12 @@ func (opts options) validate() (report.Config, error) {
 		RowLimit:      opts.rowLimit,
 		TruncLimit:    opts.strLimit,
 		Rate:          opts.rate,
+		ViewsFile:     opts.viewsFile,
+		Format:        opts.format,
+		Summary:       opts.summary,
+		CompareFile:   opts.compareFile,
+		CompareStart:  cmpStart,
+		CompareEnd:    cmpEnd,
 	}, nil
 }
 
@@ -165,6 +207,16 @@ func selectReport(opts options) string {
 		case "i":
 			return "progress_index"
 		}
+	case opts.showCustom != "":
+		return opts.showCustom
+	case opts.showSysstat:
+		return "sysstat"
+	case opts.showDiskstats:
+		return "diskstats"
+	case opts.showNetdev:
+		return "netdev"
+	case opts.showASH:
+		return "ash"
 	}
 
 	return ""
@@ -15,9 +15,11 @@ func Test_options_validate(t *testing.T) {
 	}{
 		{valid: true, opts: options{showActivity: true, tsStart: "2021-01-01 12:00:00", tsEnd: "2021-01-01 13:00:00", rate: time.Second}},
 		{valid: true, opts: options{showActivity: true, tsStart: "2021-01-01 12:00:00", tsEnd: "2021-01-01 13:00:00", rate: 0}},
-		{valid: false, opts: options{tsStart: "2021-01-01 12:00:00", tsEnd: "2021-01-01 13:00:00", rate: time.Second}}, // no report type specified
-		{valid: false, opts: options{showActivity: true, tsStart: "2021-01-32", rate: time.Second}},                    // invalid report start timestamp
-		{valid: false, opts: options{showActivity: true, filter: `colname:"["`, rate: time.Second}},                    // invalid regexp
+		{valid: false, opts: options{tsStart: "2021-01-01 12:00:00", tsEnd: "2021-01-01 13:00:00", rate: time.Second}},   // no report type specified
+		{valid: false, opts: options{showActivity: true, tsStart: "2021-01-32", rate: time.Second}},                      // invalid report start timestamp
+		{valid: false, opts: options{showActivity: true, filter: `colname:"["`, rate: time.Second}},                      // invalid regexp
+		{valid: false, opts: options{showTables: true, summary: true, compareFile: "other.stat.tar", rate: time.Second}}, // summary with compare
+		{valid: false, opts: options{showTables: true, compareFile: "other.stat.tar", compareEnd: "invalid", rate: time.Second}},
 	}
 
 	for _, tc := range testcases {
@@ -51,6 +53,11 @@ func Test_selectReport(t *testing.T) {
 		{opts: options{showProgress: "v"}, want: "progress_vacuum"},
 		{opts: options{showProgress: "c"}, want: "progress_cluster"},
 		{opts: options{showProgress: "i"}, want: "progress_index"},
+		{opts: options{showCustom: "queue"}, want: "queue"},
+		{opts: options{showSysstat: true}, want: "sysstat"},
+		{opts: options{showDiskstats: true}, want: "diskstats"},
+		{opts: options{showNetdev: true}, want: "netdev"},
+		{opts: options{showASH: true}, want: "ash"},
 		{opts: options{}, want: ""},
 	}
 
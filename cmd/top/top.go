@@ -9,7 +9,8 @@ import (
 )
 
 var (
-	opts postgres.ConnectionOptions
+	opts      postgres.ConnectionOptions
+	topConfig top.Config
 
 	// CommandDefinition defines 'top' sub-command.
 	CommandDefinition = &cobra.Command{
@@ -22,13 +23,18 @@ var (
 				opts.ParseExtraArgs(args)
 			}
 
+			// Replay recorded stats, connection is not required.
+			if topConfig.ReplayFile != "" {
+				return top.RunMain(postgres.Config{}, topConfig)
+			}
+
 			// Create connection config.
 			pgConfig, err := postgres.NewConfig(opts.Host, opts.Port, opts.User, opts.Dbname)
 			if err != nil {
 				return err
 			}
 
-			return top.RunMain(pgConfig)
+			return top.RunMain(pgConfig, topConfig)
 		},
 	}
 )
@@ -39,4 +45,11 @@ func init() {
 	CommandDefinition.Flags().IntVarP(&opts.Port, "port", "p", 0, "database server port")
 	CommandDefinition.Flags().StringVarP(&opts.User, "username", "U", "", "database user name")
 	CommandDefinition.Flags().StringVarP(&opts.Dbname, "dbname", "d", "", "database name to connect to")
+	CommandDefinition.Flags().StringVarP(&topConfig.Profile, "profile", "", "default", "name of the settings profile to use")
+	CommandDefinition.Flags().IntVarP(&topConfig.HistorySize, "history", "", 300, "number of stats snapshots kept in history")
+	CommandDefinition.Flags().StringVarP(&topConfig.ReplayFile, "from-file", "", "", "replay stats from file, directory or glob pattern of files recorded by 'pgcenter record'")
+	CommandDefinition.Flags().BoolVarP(&topConfig.ReadOnly, "read-only", "", false, "disable actions which change state of Postgres (signals, reload, stats reset, config editing, psql)")
+	CommandDefinition.Flags().StringVarP(&topConfig.AuditFile, "audit-log", "", "", "append records about actions which change state of Postgres to file (JSON lines)")
+	CommandDefinition.Flags().StringVarP(&topConfig.AuditTarget, "audit-postgres", "", "", "also send audit records to Postgres: log (RAISE LOG), notify (pg_notify)")
+	CommandDefinition.Flags().StringVarP(&topConfig.ViewsFile, "views-file", "", "", "file with user-defined views (default: $HOME/.config/pgcenter/views.yaml)")
 }
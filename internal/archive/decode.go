@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"io"
+	"strings"
+)
+
+// Decoder restores stats of the view written into compressed archives as deltas to the previous stats.
+type Decoder struct {
+	base    stat.PGresult // the latest restored stats
+	pending []byte        // data of the latest full stats (keyframe) which are not decoded yet
+}
+
+// deltaReader is implemented by readers which know whether the read archive might contain stats written as deltas.
+type deltaReader interface {
+	mayContainDeltas() bool
+}
+
+// mayContainDeltas returns true if entries of the reader might be written as deltas. Readers which don't know it
+// are considered as they might.
+func mayContainDeltas(r io.Reader) bool {
+	if dr, ok := r.(deltaReader); ok {
+		return dr.mayContainDeltas()
+	}
+	return true
+}
+
+// IsDeltaEntry returns true if entry contains stats written as delta to the previous stats.
+func IsDeltaEntry(name string) bool {
+	return strings.HasSuffix(name, ".delta")
+}
+
+// Read reads entry and returns restored stats.
+func (d *Decoder) Read(r io.Reader, size int64, delta bool) (stat.PGresult, error) {
+	if !delta {
+		res, err := ReadResult(r, size)
+		if err != nil {
+			return stat.PGresult{}, err
+		}
+
+		d.base, d.pending = res, nil
+		return res, nil
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return stat.PGresult{}, err
+	}
+
+	return d.apply(data)
+}
+
+// Skip reads entry which is not requested, but might be required for restoring the next stats. Full stats are not
+// decoded until they are required. Full stats of archives without deltas are not read at all, the unread data is
+// skipped by tar reader when advancing to the next entry.
+func (d *Decoder) Skip(r io.Reader, size int64, delta bool) error {
+	if !delta && !mayContainDeltas(r) {
+		d.base, d.pending = stat.PGresult{}, nil
+		return nil
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	if !delta {
+		d.base, d.pending = stat.PGresult{}, data
+		return nil
+	}
+
+	_, err := d.apply(data)
+	return err
+}
+
+// apply applies delta to the latest restored stats.
+func (d *Decoder) apply(data []byte) (stat.PGresult, error) {
+	if d.pending != nil {
+		res := stat.PGresult{}
+		err := json.Unmarshal(d.pending, &res)
+		if err != nil {
+			return stat.PGresult{}, err
+		}
+
+		d.base, d.pending = res, nil
+	}
+
+	if !d.base.Valid {
+		return stat.PGresult{}, fmt.Errorf("no full stats found for stats delta")
+	}
+
+	var delta stat.ResultDelta
+	err := json.Unmarshal(data, &delta)
+	if err != nil {
+		return stat.PGresult{}, err
+	}
+
+	res, err := delta.Apply(d.base)
+	if err != nil {
+		return stat.PGresult{}, err
+	}
+
+	d.base = res
+	return res, nil
+}
+
+// ReadResult reads content of the entry, unmarshal data and return stat object.
+func ReadResult(r io.Reader, bufsz int64) (stat.PGresult, error) {
+	data := make([]byte, bufsz)
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		return stat.PGresult{}, err
+	}
+
+	// initialize an empty struct and unmarshal data from the buffer
+	res := stat.PGresult{}
+	err := json.Unmarshal(data, &res)
+	if err != nil {
+		return stat.PGresult{}, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"database/sql"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	// Delta without keyframe can't be restored.
+	var dec Decoder
+	_, err := dec.apply([]byte(`{"Key":0}`))
+	assert.Error(t, err)
+
+	_, err = dec.Read(nil, 0, false)
+	assert.Error(t, err)
+
+	res := stat.PGresult{Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"a"}, Values: [][]sql.NullString{{{String: "1", Valid: true}}}}
+	dec.base = res
+	got, err := dec.apply([]byte(`{"Key":0,"Removed":["1"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, got.Nrows)
+}
+
+// testDeltaReader is reader of archive which is known to contain or not contain stats deltas.
+type testDeltaReader struct {
+	*strings.Reader
+	deltas bool
+}
+
+func (r testDeltaReader) mayContainDeltas() bool { return r.deltas }
+
+func TestDecoder_Skip(t *testing.T) {
+	data := `{"values":[[{"String":"t1","Valid":true}]],"cols":["relname"],"ncols":1,"nrows":1,"valid":true}`
+
+	// Full stats of archives without deltas are not buffered.
+	var dec Decoder
+	assert.NoError(t, dec.Skip(testDeltaReader{Reader: strings.NewReader(data)}, int64(len(data)), false))
+	assert.Nil(t, dec.pending)
+
+	// Full stats of archives with deltas are kept until they are required.
+	assert.NoError(t, dec.Skip(testDeltaReader{Reader: strings.NewReader(data), deltas: true}, int64(len(data)), false))
+	assert.Equal(t, []byte(data), dec.pending)
+
+	// Readers which don't know about deltas are considered as they might contain deltas.
+	dec = Decoder{}
+	assert.NoError(t, dec.Skip(strings.NewReader(data), int64(len(data)), false))
+	assert.Equal(t, []byte(data), dec.pending)
+}
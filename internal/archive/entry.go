@@ -0,0 +1,19 @@
+package archive
+
+const (
+	// MetadataEntryName defines name of the entry which describes recording session. The entry is written at the
+	// beginning of every recording session and every new archive.
+	MetadataEntryName = "meta"
+	// GapEntryName defines name of the entry which marks beginning of the gap in recorded stats.
+	GapEntryName = "gap"
+	// ASHEntryName defines name of the entry with samples of active sessions.
+	ASHEntryName = "ash"
+	// ASHQueriesEntryName defines name of the entry with texts of sampled queries.
+	ASHQueriesEntryName = "ash_queries"
+)
+
+// ReservedEntryNames defines names of entries which have special meaning in archives: service entries and entries
+// with system stats. Names of user-defined views must not match them.
+var ReservedEntryNames = []string{
+	MetadataEntryName, GapEntryName, ASHEntryName, ASHQueriesEntryName, "sysstat", "diskstats", "netdev",
+}
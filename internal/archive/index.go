@@ -0,0 +1,141 @@
+// Package archive provides reading and index of stats archives written by 'pgcenter record'. The index is stored in
+// a sidecar file next to the archive and maps archive entries (stats views and timestamps) to their offsets, hence
+// stats of the requested time interval could be read without scanning the whole archive.
+package archive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// indexSuffix defines suffix of index file name, which is added to the archive name.
+	indexSuffix = ".idx"
+
+	// entryTsFormat defines format of timestamps used in names of archive entries.
+	entryTsFormat = "20060102T150405"
+)
+
+// IndexEntry describes location of the archive entry.
+type IndexEntry struct {
+	Name     string    // name of the archive entry in format 'view.timestamp.ext'
+	View     string    // name of the stats view
+	Ts       time.Time // time when stats have been recorded
+	Snapshot int64     // offset of the snapshot which contains the entry, reading of the archive could be started there
+	Offset   int64     // offset of the entry's data, -1 if data can't be read directly (e.g. in compressed archives)
+	Size     int64     // size of the entry's data
+}
+
+// IndexName returns name of the index file of the archive.
+func IndexName(archive string) string {
+	return archive + indexSuffix
+}
+
+// IsIndexName returns true if file name is the name of index file.
+func IsIndexName(name string) bool {
+	return strings.HasSuffix(name, indexSuffix)
+}
+
+// WriteIndex writes entries to the index, one entry per line.
+func WriteIndex(w io.Writer, entries []IndexEntry) error {
+	for _, e := range entries {
+		_, err := fmt.Fprintf(w, "%s %d %d %d\n", e.Name, e.Snapshot, e.Offset, e.Size)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadIndex reads index of the archive. Index is considered valid only if it describes the archive from the beginning
+// and entries are ordered by their location, otherwise error is returned and the archive should be read as usual.
+func ReadIndex(archive string) ([]IndexEntry, error) {
+	f, err := os.Open(filepath.Clean(IndexName(archive)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	entries, err := parseIndex(f)
+	if err != nil {
+		return nil, fmt.Errorf("read index %s failed: %s", IndexName(archive), err)
+	}
+
+	return entries, nil
+}
+
+// parseIndex parses index entries and validates them.
+func parseIndex(r io.Reader) ([]IndexEntry, error) {
+	var entries []IndexEntry
+	var line int
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: invalid number of fields", line)
+		}
+
+		e := IndexEntry{Name: fields[0]}
+
+		var err error
+		e.View, e.Ts, err = ParseEntryName(e.Name)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		values := make([]int64, 3)
+		for i, s := range fields[1:] {
+			values[i], err = strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+		}
+		e.Snapshot, e.Offset, e.Size = values[0], values[1], values[2]
+
+		// Entries are appended when snapshots are written, hence snapshots' offsets are never decreasing.
+		if len(entries) == 0 && e.Snapshot != 0 {
+			return nil, fmt.Errorf("line %d: index doesn't start from the beginning of the archive", line)
+		}
+		if len(entries) > 0 && e.Snapshot < entries[len(entries)-1].Snapshot {
+			return nil, fmt.Errorf("line %d: entries are not ordered", line)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("index is empty")
+	}
+
+	return entries, nil
+}
+
+// ParseEntryName parses name of the archive entry in format 'view.timestamp.ext' and returns name of the view and
+// timestamp. Timestamp is parsed in local timezone.
+func ParseEntryName(name string) (string, time.Time, error) {
+	s := strings.Split(name, ".")
+	if len(s) != 3 {
+		return "", time.Time{}, fmt.Errorf("bad entry name format %s", name)
+	}
+
+	ts, err := time.ParseInLocation(entryTsFormat, s[1], time.Now().Location())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return s[0], ts, nil
+}
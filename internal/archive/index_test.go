@@ -0,0 +1,104 @@
+package archive
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-archive-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	entries := []IndexEntry{
+		{Name: "databases.20210123T150405.json", Snapshot: 0, Offset: 512, Size: 100},
+		{Name: "tables.20210123T150405.json", Snapshot: 0, Offset: 1536, Size: 200},
+		{Name: "databases.20210123T150415.delta", Snapshot: 2048, Offset: -1, Size: 50},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteIndex(&buf, entries))
+
+	filename := filepath.Join(dir, "pgcenter.stat.tar")
+	assert.NoError(t, ioutil.WriteFile(IndexName(filename), buf.Bytes(), 0600))
+
+	got, err := ReadIndex(filename)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	for i := range entries {
+		assert.Equal(t, entries[i].Name, got[i].Name)
+		assert.Equal(t, entries[i].Snapshot, got[i].Snapshot)
+		assert.Equal(t, entries[i].Offset, got[i].Offset)
+		assert.Equal(t, entries[i].Size, got[i].Size)
+	}
+
+	assert.Equal(t, "tables", got[1].View)
+	assert.Equal(t, time.Date(2021, 1, 23, 15, 4, 15, 0, time.Now().Location()), got[2].Ts)
+
+	// Archive without index.
+	_, err = ReadIndex(filepath.Join(dir, "unknown.tar"))
+	assert.Error(t, err)
+}
+
+func Test_parseIndex(t *testing.T) {
+	testcases := []struct {
+		index string
+		valid bool
+	}{
+		{index: "databases.20210123T150405.json 0 512 100\n", valid: true},
+		{index: "databases.20210123T150405.json 0 512 100\ndatabases.20210123T150415.json 1024 1536 100\n", valid: true},
+		{index: "", valid: false},
+		{index: "databases.20210123T150405.json 0 512\n", valid: false},
+		{index: "databases.20210123T150405.json 0 512 invalid\n", valid: false},
+		{index: "databases.invalid.json 0 512 100\n", valid: false},
+		{index: "databases.20210123T150405.json 1024 1536 100\n", valid: false},
+		{index: "databases.20210123T150405.json 0 512 100\ndatabases.20210123T150415.json 1024 1536 100\ndatabases.20210123T150425.json 512 1024 100\n", valid: false},
+	}
+
+	for _, tc := range testcases {
+		_, err := parseIndex(strings.NewReader(tc.index))
+		if tc.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestIsIndexName(t *testing.T) {
+	assert.True(t, IsIndexName(IndexName("pgcenter.stat.tar")))
+	assert.False(t, IsIndexName("pgcenter.stat.tar"))
+	assert.False(t, IsIndexName("pgcenter.stat.tar.gz"))
+}
+
+func TestParseEntryName(t *testing.T) {
+	testcases := []struct {
+		name  string
+		view  string
+		valid bool
+	}{
+		{name: "databases.20210123T150405.json", view: "databases", valid: true},
+		{name: "gap.20210123T150405.json", view: "gap", valid: true},
+		{name: "tables.20210123T150405.delta", view: "tables", valid: true},
+		{name: "databases.20210123T150405", valid: false},
+		{name: "databases.invalid.json", valid: false},
+	}
+
+	for _, tc := range testcases {
+		view, ts, err := ParseEntryName(tc.name)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.view, view)
+			assert.Equal(t, time.Date(2021, 1, 23, 15, 4, 5, 0, time.Now().Location()), ts)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package archive
+
+import (
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"time"
+)
+
+// Metadata describes recording session: where and how stats have been recorded.
+type Metadata struct {
+	Hostname    string                  // name of the host where pgcenter has been running
+	Version     string                  // version of pgcenter used for recording
+	Postgres    stat.PostgresProperties // properties of the recorded Postgres
+	Options     query.Options           // options used for building views' queries
+	Views       map[string][]string     // recorded views and their columns
+	Interval    time.Duration           // recording interval
+	ASHInterval time.Duration           // interval of active sessions sampling, zero if sessions are not sampled
+}
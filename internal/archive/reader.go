@@ -0,0 +1,313 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reader reads entries of several archives sequentially, as if it is a single archive. Plain tar archives
+// and compressed archives are detected automatically.
+type Reader struct {
+	files  []string    // names of archives
+	next   int         // index of the next archive to open
+	file   *os.File    // currently opened archive
+	reader *tar.Reader // reader of currently opened archive
+	window *window     // stats interval requested for reading, nil if the whole archives are read
+	// currently opened archive is compressed, stats written as deltas might be found only in compressed archives
+	compressed bool
+}
+
+// window defines stats interval requested for reading. Archives with index are read only within the interval.
+type window struct {
+	view  string    // name of the requested view
+	start time.Time // start of the interval
+	end   time.Time // end of the interval
+	meta  bool      // only metadata of recording session is requested
+}
+
+// NewReader creates reader of archives specified by file name, directory or glob pattern.
+func NewReader(name string) (*Reader, error) {
+	files, err := ListArchives(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{files: files}, nil
+}
+
+// ListArchives returns list of archives specified by file name, directory or glob pattern. Archives of directory or
+// glob pattern are sorted by names, names of rotated archives contain timestamps, hence archives are sorted by time.
+func ListArchives(name string) ([]string, error) {
+	st, err := os.Stat(name)
+	switch {
+	case err == nil && !st.IsDir():
+		return []string{name}, nil
+	case err == nil && st.IsDir():
+		entries, err := ioutil.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var files []string
+		for _, e := range entries {
+			if !e.IsDir() && (strings.HasSuffix(e.Name(), ".tar") || strings.HasSuffix(e.Name(), ".tar.gz")) {
+				files = append(files, filepath.Join(name, e.Name()))
+			}
+		}
+
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no stats files found in %s", name)
+		}
+
+		return files, nil
+	case strings.ContainsAny(name, `*?[`):
+		matches, err := filepath.Glob(name)
+		if err != nil {
+			return nil, err
+		}
+
+		// Pattern might match index files of archives, skip them.
+		var files []string
+		for _, m := range matches {
+			if !IsIndexName(m) {
+				files = append(files, m)
+			}
+		}
+
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no stats files found matching %s", name)
+		}
+
+		sort.Strings(files)
+		return files, nil
+	default:
+		return nil, err
+	}
+}
+
+// Seek limits reading of archives with the requested view and interval. Archives which have index are read only
+// within the interval, other archives are read completely. Stats out of the interval still might be read, hence they
+// should be filtered by reader's user.
+func (r *Reader) Seek(view string, start, end time.Time) {
+	r.window = &window{view: view, start: start, end: end}
+}
+
+// SeekMetadata limits reading of archives with metadata of recording sessions. Archives which have index are read
+// only within snapshots with the latest metadata recorded not after the specified time, or the earliest metadata if
+// there is no such metadata.
+func (r *Reader) SeekMetadata(ts time.Time) {
+	r.window = &window{start: ts, meta: true}
+}
+
+// Next advances to the next entry, archives are opened one by one when entries of previous archive are exhausted.
+func (r *Reader) Next() (*tar.Header, error) {
+	for {
+		if r.reader != nil {
+			hdr, err := r.reader.Next()
+			if err != io.EOF {
+				return hdr, err
+			}
+		}
+
+		if r.next >= len(r.files) {
+			return nil, io.EOF
+		}
+
+		err := r.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := os.Open(r.files[r.next])
+		if err != nil {
+			return nil, err
+		}
+
+		r.next++
+		r.file = f
+
+		sr, err := r.section(f)
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed: %s", f.Name(), err)
+		}
+
+		tr, compressed, err := newTarReader(sr)
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed: %s", f.Name(), err)
+		}
+		r.reader, r.compressed = tr, compressed
+	}
+}
+
+// section returns part of the archive which should be read. When interval is requested and archive has valid index,
+// the part contains only snapshots required for reading stats of the interval, otherwise the whole archive is read.
+func (r *Reader) section(f *os.File) (io.ReadSeeker, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := int64(0), st.Size()
+
+	if r.window != nil {
+		// Index is optional, archives without index (or with broken index) are read completely.
+		if entries, err := ReadIndex(f.Name()); err == nil {
+			if r.window.meta {
+				from, to = metadataSection(entries, r.window.start, to)
+			} else {
+				from, to = indexSection(entries, *r.window, to)
+			}
+		}
+	}
+
+	if from >= to {
+		return io.NewSectionReader(f, 0, 0), nil
+	}
+
+	return io.NewSectionReader(f, from, to-from), nil
+}
+
+// indexSection returns offsets of the archive part which contains snapshots of the requested interval. Snapshots
+// are written one by one, hence they are ordered by time and location in the archive. Reading starts from the first
+// snapshot recorded not before the interval start. If the view is written as deltas, reading starts from the nearest
+// preceding full snapshot of the view. Reading stops at the first snapshot recorded after the interval end.
+func indexSection(entries []IndexEntry, w window, size int64) (int64, int64) {
+	// Snapshots might be written after the latest index update, read the latest snapshot and the rest of archive
+	// if the interval is not found in the index.
+	from := entries[len(entries)-1].Snapshot
+	first := len(entries)
+	for i, e := range entries {
+		if !e.Ts.Before(w.start) {
+			from, first = e.Snapshot, i
+			break
+		}
+	}
+
+	// Look for the nearest preceding full snapshot of the view if the view is written as deltas. Snapshots which
+	// are not indexed might contain deltas too.
+	if next, ok := nextViewEntry(entries, w.view, first); !ok || IsDeltaEntry(next.Name) {
+		for i := first - 1; i >= 0; i-- {
+			e := entries[i]
+			if e.View == w.view && !IsDeltaEntry(e.Name) {
+				if e.Snapshot < from {
+					from = e.Snapshot
+				}
+				break
+			}
+		}
+	}
+
+	to := size
+	for _, e := range entries[first:] {
+		if e.Ts.After(w.end) {
+			to = e.Snapshot
+			break
+		}
+	}
+
+	return from, to
+}
+
+// metadataSection returns offsets of the archive part which contains snapshot with metadata of recording session
+// which has been running at the specified time, or the earliest metadata if sessions have been started later.
+func metadataSection(entries []IndexEntry, ts time.Time, size int64) (int64, int64) {
+	found := -1
+	for i, e := range entries {
+		if e.View != MetadataEntryName {
+			continue
+		}
+
+		if found >= 0 && e.Ts.After(ts) {
+			break
+		}
+		found = i
+
+		if e.Ts.After(ts) {
+			break
+		}
+	}
+
+	if found < 0 {
+		return 0, 0
+	}
+
+	// Read only the snapshot with metadata.
+	from, to := entries[found].Snapshot, size
+	for _, e := range entries[found:] {
+		if e.Snapshot > from {
+			to = e.Snapshot
+			break
+		}
+	}
+
+	return from, to
+}
+
+// nextViewEntry returns the first entry of the view starting from specified position.
+func nextViewEntry(entries []IndexEntry, view string, from int) (IndexEntry, bool) {
+	for _, e := range entries[from:] {
+		if e.View == view {
+			return e, true
+		}
+	}
+
+	return IndexEntry{}, false
+}
+
+// newTarReader creates tar reader for plain or compressed archive. Returns true if archive is compressed.
+func newTarReader(f io.ReadSeeker) (*tar.Reader, bool, error) {
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+
+	// Plain archives are read directly from the file, it allows to skip entries without reading them.
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		_, err := f.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, false, err
+		}
+		return tar.NewReader(f), false, nil
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return tar.NewReader(gz), true, nil
+}
+
+// mayContainDeltas returns true if the currently read archive might contain stats written as deltas.
+func (r *Reader) mayContainDeltas() bool {
+	return r.compressed
+}
+
+// Read reads from the current entry.
+func (r *Reader) Read(b []byte) (int, error) {
+	if r.reader == nil {
+		return 0, io.EOF
+	}
+	return r.reader.Read(b)
+}
+
+// Close closes currently opened archive.
+func (r *Reader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file, r.reader = nil, nil
+	return err
+}
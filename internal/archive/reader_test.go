@@ -0,0 +1,235 @@
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-archive-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	// Write archives in reverse order, they must be read ordered by names.
+	archives := map[string][]string{
+		"pgcenter.stat.20210123T160000.tar": {"c", "d"},
+		"pgcenter.stat.20210123T150000.tar": {"a", "b"},
+	}
+	for name, entries := range archives {
+		f, err := os.Create(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		tw := tar.NewWriter(f)
+		for _, e := range entries {
+			assert.NoError(t, tw.WriteHeader(&tar.Header{Name: e, Mode: 0644, Size: int64(len(e))}))
+			_, err = tw.Write([]byte(e))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, tw.Close())
+		assert.NoError(t, f.Close())
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("example"), 0600))
+
+	testcases := []struct {
+		name string
+		want []string
+	}{
+		{name: dir, want: []string{"a", "b", "c", "d"}},
+		{name: filepath.Join(dir, "pgcenter.stat.*.tar"), want: []string{"a", "b", "c", "d"}},
+		{name: filepath.Join(dir, "pgcenter.stat.20210123T16*.tar"), want: []string{"c", "d"}},
+		{name: filepath.Join(dir, "pgcenter.stat.20210123T150000.tar"), want: []string{"a", "b"}},
+	}
+
+	for _, tc := range testcases {
+		r, err := NewReader(tc.name)
+		assert.NoError(t, err)
+
+		var got []string
+		for {
+			hdr, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+
+			data, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, hdr.Name, string(data))
+			got = append(got, hdr.Name)
+		}
+		assert.Equal(t, tc.want, got)
+		assert.NoError(t, r.Close())
+	}
+
+	// Missing files and directory without archives.
+	emptyDir := filepath.Join(dir, "empty")
+	assert.NoError(t, os.Mkdir(emptyDir, 0700))
+
+	for _, name := range []string{filepath.Join(dir, "unknown.tar"), filepath.Join(dir, "unknown.*.tar"), emptyDir} {
+		_, err := NewReader(name)
+		assert.Error(t, err)
+	}
+}
+
+func Test_indexSection(t *testing.T) {
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+	newEntries := func(names ...string) []IndexEntry {
+		var entries []IndexEntry
+		for i, name := range names {
+			// Every snapshot contains single entry and takes 1000 bytes.
+			view, ext := strings.Split(name, ".")[0], strings.Split(name, ".")[1]
+			e := IndexEntry{
+				Name: fmt.Sprintf("%s.%s.%s", view, ts.Add(time.Duration(i)*time.Minute).Format("20060102T150405"), ext),
+				View: view, Ts: ts.Add(time.Duration(i) * time.Minute), Snapshot: int64(i * 1000),
+			}
+			entries = append(entries, e)
+		}
+		return entries
+	}
+
+	plain := newEntries("tables.json", "tables.json", "tables.json", "tables.json", "tables.json")
+	compressed := newEntries("tables.json", "tables.delta", "tables.delta", "tables.json", "tables.delta")
+	mixed := newEntries("tables.json", "indexes.json", "gap.json", "tables.json", "indexes.json")
+
+	testcases := []struct {
+		entries    []IndexEntry
+		start, end time.Duration
+		from, to   int64
+	}{
+		// Interval covers the whole archive.
+		{entries: plain, start: -time.Hour, end: time.Hour, from: 0, to: 5000},
+		// Interval in the middle of archive.
+		{entries: plain, start: 1 * time.Minute, end: 3 * time.Minute, from: 1000, to: 4000},
+		{entries: plain, start: 90 * time.Second, end: 150 * time.Second, from: 2000, to: 3000},
+		// Interval after the archive, the latest snapshot might be followed by not indexed snapshots.
+		{entries: plain, start: time.Hour, end: 2 * time.Hour, from: 4000, to: 5000},
+		// Interval before the archive.
+		{entries: plain, start: -2 * time.Hour, end: -time.Hour, from: 0, to: 0},
+		// Deltas require the preceding full snapshot.
+		{entries: compressed, start: 2 * time.Minute, end: time.Hour, from: 0, to: 5000},
+		{entries: compressed, start: 3 * time.Minute, end: time.Hour, from: 3000, to: 5000},
+		{entries: compressed, start: 4 * time.Minute, end: 4 * time.Minute, from: 3000, to: 5000},
+		// Snapshots of other views and gaps within the interval are read too.
+		{entries: mixed, start: 2 * time.Minute, end: 3 * time.Minute, from: 2000, to: 4000},
+	}
+
+	for _, tc := range testcases {
+		from, to := indexSection(tc.entries, window{view: "tables", start: ts.Add(tc.start), end: ts.Add(tc.end)}, 5000)
+		assert.Equal(t, tc.from, from)
+		assert.Equal(t, tc.to, to)
+	}
+}
+
+func TestReader_Seek(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-archive-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	// Write archive with index, every snapshot contains single entry.
+	filename := filepath.Join(dir, "pgcenter.stat.tar")
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+	tw := tar.NewWriter(f)
+	var entries []IndexEntry
+	for i := 0; i < 10; i++ {
+		offset, err := f.Seek(0, io.SeekCurrent)
+		assert.NoError(t, err)
+
+		name := fmt.Sprintf("tables.%s.json", ts.Add(time.Duration(i)*time.Minute).Format("20060102T150405"))
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(name))}))
+		_, err = tw.Write([]byte(name))
+		assert.NoError(t, err)
+		assert.NoError(t, tw.Flush())
+
+		entries = append(entries, IndexEntry{Name: name, Snapshot: offset, Offset: offset + 512, Size: int64(len(name))})
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, f.Close())
+
+	idx, err := os.Create(IndexName(filename))
+	assert.NoError(t, err)
+	assert.NoError(t, WriteIndex(idx, entries))
+	assert.NoError(t, idx.Close())
+
+	read := func(r *Reader) []string {
+		var got []string
+		for {
+			hdr, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			got = append(got, hdr.Name)
+		}
+		assert.NoError(t, r.Close())
+		return got
+	}
+
+	// Only snapshots of the interval are read.
+	r, err := NewReader(filename)
+	assert.NoError(t, err)
+	r.Seek("tables", ts.Add(3*time.Minute), ts.Add(5*time.Minute))
+	assert.Equal(t, []string{entries[3].Name, entries[4].Name, entries[5].Name}, read(r))
+
+	// Archive is read completely without the requested interval or with broken index.
+	r, err = NewReader(filename)
+	assert.NoError(t, err)
+	assert.Len(t, read(r), 10)
+
+	assert.NoError(t, ioutil.WriteFile(IndexName(filename), []byte("invalid"), 0600))
+	r, err = NewReader(filename)
+	assert.NoError(t, err)
+	r.Seek("tables", ts.Add(3*time.Minute), ts.Add(5*time.Minute))
+	assert.Len(t, read(r), 10)
+
+	// Index files are skipped when archives are specified by pattern.
+	files, err := ListArchives(filepath.Join(dir, "pgcenter.stat.*"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filename}, files)
+}
+
+func Test_metadataSection(t *testing.T) {
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+	newEntry := func(name string, i int) IndexEntry {
+		return IndexEntry{View: name, Ts: ts.Add(time.Duration(i) * time.Minute), Snapshot: int64(i * 1000)}
+	}
+
+	entries := []IndexEntry{
+		newEntry("meta", 0), newEntry("databases", 0), newEntry("databases", 1),
+		newEntry("meta", 2), newEntry("databases", 2), newEntry("databases", 3),
+	}
+
+	testcases := []struct {
+		start    time.Duration
+		from, to int64
+	}{
+		{start: -time.Hour, from: 0, to: 1000},
+		{start: time.Minute, from: 0, to: 1000},
+		{start: 2 * time.Minute, from: 2000, to: 3000},
+		{start: time.Hour, from: 2000, to: 3000},
+	}
+
+	for _, tc := range testcases {
+		from, to := metadataSection(entries, ts.Add(tc.start), 4000)
+		assert.Equal(t, tc.from, from)
+		assert.Equal(t, tc.to, to)
+	}
+
+	// Metadata in the latest snapshot.
+	from, to := metadataSection(entries[:4], ts.Add(time.Hour), 4000)
+	assert.Equal(t, int64(2000), from)
+	assert.Equal(t, int64(4000), to)
+
+	// No metadata in archive.
+	from, to = metadataSection(entries[1:3], ts, 4000)
+	assert.Equal(t, from, to)
+}
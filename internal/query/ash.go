@@ -0,0 +1,15 @@
+package query
+
+const (
+	// SelectActiveSessions queries sessions which are not idle, used for sampling of active sessions (Active Session
+	// History). Sessions executing the same query are identified by query_id (Postgres 14 and newer) or by hash of
+	// query text.
+	// regexp_replace() removes extra spaces, tabs and newlines from queries
+	SelectActiveSessions = "SELECT pid, coalesce(usename, '') AS usename, coalesce(datname, '') AS datname, state, " +
+		"coalesce(wait_event_type, '') AS wait_event_type, coalesce(wait_event, '') AS wait_event, " +
+		"{{ if ge .Version 140000 }}coalesce(query_id::text, left(md5(query), 16)){{ else }}left(md5(query), 16){{ end }} AS query_id, " +
+		`regexp_replace(regexp_replace({{.PgSSQueryLenFn}},E'( |\t)+', ' ', 'g'),E'\n', ' ', 'g') AS query ` +
+		"FROM pg_stat_activity p " +
+		"WHERE pid <> pg_backend_pid() AND state <> 'idle'" +
+		"{{ if ge .Version 100000 }} AND backend_type = 'client backend'{{ end }}"
+)
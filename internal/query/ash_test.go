@@ -0,0 +1,49 @@
+package query
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func Test_SelectActiveSessions(t *testing.T) {
+	testcases := []struct {
+		version   int
+		queryID   bool
+		backendTy bool
+	}{
+		{version: 90600, queryID: false, backendTy: false},
+		{version: 100000, queryID: false, backendTy: true},
+		{version: 140000, queryID: true, backendTy: true},
+	}
+
+	for _, tc := range testcases {
+		q, err := Format(SelectActiveSessions, NewOptions(tc.version, "f", "off", 256))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.queryID, strings.Contains(q, "query_id::text"))
+		assert.Equal(t, tc.backendTy, strings.Contains(q, "backend_type"))
+		assert.Contains(t, q, "left(p.query, 256)")
+	}
+}
+
+func Test_ActiveSessionsQueries(t *testing.T) {
+	versions := []int{90600, 100000, 110000, 120000, 130000}
+
+	for _, version := range versions {
+		t.Run(fmt.Sprintf("ash/%d", version), func(t *testing.T) {
+			opts := NewOptions(version, "f", "off", 256)
+			q, err := Format(SelectActiveSessions, opts)
+			assert.NoError(t, err)
+
+			conn, err := postgres.NewTestConnectVersion(version)
+			assert.NoError(t, err)
+
+			_, err = conn.Exec(q)
+			assert.NoError(t, err)
+
+			conn.Close()
+		})
+	}
+}
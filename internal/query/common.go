@@ -22,17 +22,25 @@ const (
 	// ExecTerminateBackend terminates the backend with specified PID
 	ExecTerminateBackend = "SELECT pg_terminate_backend($1)"
 	// ExecCancelQueryGroup cancels a group of queries based on specified criteria
-	ExecCancelQueryGroup = "SELECT count(pg_cancel_backend(pid)) " +
+	ExecCancelQueryGroup = "SELECT pid, coalesce(query, ''), pg_cancel_backend(pid) " +
 		"FROM pg_stat_activity WHERE {{.BackendState}} " +
 		"AND ((clock_timestamp() - xact_start) > '{{.QueryAgeThresh}}'::interval " +
 		"OR (clock_timestamp() - query_start) > '{{.QueryAgeThresh}}'::interval) " +
 		"AND pid != pg_backend_pid()"
 	// ExecTerminateBackendGroup terminate a group of backends based on specified criteria
-	ExecTerminateBackendGroup = "SELECT count(pg_terminate_backend(pid)) " +
+	ExecTerminateBackendGroup = "SELECT pid, coalesce(query, ''), pg_terminate_backend(pid) " +
 		"FROM pg_stat_activity WHERE {{.BackendState}} " +
 		"AND ((clock_timestamp() - xact_start) > '{{.QueryAgeThresh}}'::interval " +
 		"OR (clock_timestamp() - query_start) > '{{.QueryAgeThresh}}'::interval) " +
 		"AND pid != pg_backend_pid()"
+	// GetBackendQuery queries the current query of the backend with specified PID
+	GetBackendQuery = "SELECT coalesce(query, '') FROM pg_stat_activity WHERE pid = $1"
+	// ExecSetAuditRecord stores audit record in the session setting, used before writing the record into Postgres log
+	ExecSetAuditRecord = "SELECT set_config('pgcenter.audit_record', $1, false)"
+	// ExecRaiseAuditRecord writes audit record stored in the session setting into Postgres log
+	ExecRaiseAuditRecord = "DO $$ BEGIN RAISE LOG 'pgcenter audit: %', current_setting('pgcenter.audit_record'); END $$"
+	// ExecNotifyAuditRecord sends audit record to the notification channel
+	ExecNotifyAuditRecord = "SELECT pg_notify($1, $2)"
 	// ExecResetStats resets statistics counter in the current database
 	ExecResetStats = "SELECT pg_stat_reset()"
 	// ExecResetPgStatStatements resets pg_stat_statements statistics
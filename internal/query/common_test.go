@@ -76,6 +76,9 @@ func Test_CommonQueries(t *testing.T) {
 		{query: ExecReloadConf},
 		{query: ExecResetStats},
 		{query: ExecResetPgStatStatements},
+		{query: GetBackendQuery, args: []interface{}{1}},
+		{query: ExecSetAuditRecord, args: []interface{}{"{}"}},
+		{query: ExecNotifyAuditRecord, args: []interface{}{"pgcenter_audit", "{}"}},
 		{query: SelectCommonProperties},
 	}
 
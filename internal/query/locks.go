@@ -0,0 +1,78 @@
+package query
+
+const (
+	// NOTES:
+	// 1. Blocking chains are walked recursively starting from root blockers (those which block others, but are not
+	//    waiting themselves). Rows are ordered by chain path, waiters' pids are indented accordingly to their depth.
+	// 2. regexp_replace() removes extra spaces, tabs and newlines from queries.
+
+	// PgLocksTreeDefault is the default query for getting blocking chains based on pg_locks, pg_stat_activity and pg_blocking_pids()
+	//   Postgres 14: pg_locks.waitstart has been introduced.
+	// { Name: "locks", Query: common.PgLocksTreeDefault, DiffIntvl: [2]int{0,0}, Ncols: 10, OrderKey: 0, OrderDesc: false }
+	PgLocksTreeDefault = "WITH RECURSIVE waiters AS (" +
+		"SELECT pid, unnest(pg_blocking_pids(pid)) AS blocker FROM pg_stat_activity), " +
+		"tree AS (SELECT DISTINCT blocker AS pid, 0 AS depth, ARRAY[blocker] AS path " +
+		"FROM waiters WHERE blocker NOT IN (SELECT pid FROM waiters) " +
+		"UNION ALL SELECT w.pid, t.depth + 1, t.path || w.pid " +
+		"FROM tree t JOIN waiters w ON w.blocker = t.pid WHERE w.pid <> ALL(t.path)) " +
+		"SELECT repeat('  ', t.depth) || t.pid AS pid, a.datname, a.usename, a.state, " +
+		"l.locktype, l.mode, l.relation::regclass::text AS relation, " +
+		"date_trunc('seconds', clock_timestamp() - a.xact_start)::text AS xact_age, " +
+		"date_trunc('seconds', clock_timestamp() - l.waitstart)::text AS wait_age, " +
+		`regexp_replace(regexp_replace(a.query,E'( |\t)+', ' ', 'g'),E'\n', ' ', 'g') AS query ` +
+		"FROM tree t JOIN pg_stat_activity a ON a.pid = t.pid " +
+		"LEFT JOIN pg_locks l ON l.pid = t.pid AND NOT l.granted " +
+		"ORDER BY t.path"
+
+	// PgLocksTreePG13 queries blocking chains for versions 9.6 - 13, wait age is calculated using query_start.
+	//   Postgres 9.6: pg_blocking_pids() has been introduced.
+	PgLocksTreePG13 = "WITH RECURSIVE waiters AS (" +
+		"SELECT pid, unnest(pg_blocking_pids(pid)) AS blocker FROM pg_stat_activity), " +
+		"tree AS (SELECT DISTINCT blocker AS pid, 0 AS depth, ARRAY[blocker] AS path " +
+		"FROM waiters WHERE blocker NOT IN (SELECT pid FROM waiters) " +
+		"UNION ALL SELECT w.pid, t.depth + 1, t.path || w.pid " +
+		"FROM tree t JOIN waiters w ON w.blocker = t.pid WHERE w.pid <> ALL(t.path)) " +
+		"SELECT repeat('  ', t.depth) || t.pid AS pid, a.datname, a.usename, a.state, " +
+		"l.locktype, l.mode, l.relation::regclass::text AS relation, " +
+		"date_trunc('seconds', clock_timestamp() - a.xact_start)::text AS xact_age, " +
+		"CASE WHEN l.pid IS NOT NULL THEN date_trunc('seconds', clock_timestamp() - a.query_start)::text END AS wait_age, " +
+		`regexp_replace(regexp_replace(a.query,E'( |\t)+', ' ', 'g'),E'\n', ' ', 'g') AS query ` +
+		"FROM tree t JOIN pg_stat_activity a ON a.pid = t.pid " +
+		"LEFT JOIN pg_locks l ON l.pid = t.pid AND NOT l.granted " +
+		"ORDER BY t.path"
+
+	// PgLocksTreePG95 queries blocking chains for versions 9.5 and earlier, where pg_blocking_pids() is not available.
+	// Waiters and their blockers are matched by joining pg_locks with itself using locked object's identity.
+	PgLocksTreePG95 = "WITH RECURSIVE waiters AS (" +
+		"SELECT DISTINCT w.pid, b.pid AS blocker FROM pg_locks w JOIN pg_locks b " +
+		"ON b.locktype = w.locktype AND b.database IS NOT DISTINCT FROM w.database " +
+		"AND b.relation IS NOT DISTINCT FROM w.relation AND b.page IS NOT DISTINCT FROM w.page " +
+		"AND b.tuple IS NOT DISTINCT FROM w.tuple AND b.virtualxid IS NOT DISTINCT FROM w.virtualxid " +
+		"AND b.transactionid IS NOT DISTINCT FROM w.transactionid AND b.classid IS NOT DISTINCT FROM w.classid " +
+		"AND b.objid IS NOT DISTINCT FROM w.objid AND b.objsubid IS NOT DISTINCT FROM w.objsubid " +
+		"AND b.pid <> w.pid WHERE NOT w.granted AND b.granted), " +
+		"tree AS (SELECT DISTINCT blocker AS pid, 0 AS depth, ARRAY[blocker] AS path " +
+		"FROM waiters WHERE blocker NOT IN (SELECT pid FROM waiters) " +
+		"UNION ALL SELECT w.pid, t.depth + 1, t.path || w.pid " +
+		"FROM tree t JOIN waiters w ON w.blocker = t.pid WHERE w.pid <> ALL(t.path)) " +
+		"SELECT repeat('  ', t.depth) || t.pid AS pid, a.datname, a.usename, a.state, " +
+		"l.locktype, l.mode, l.relation::regclass::text AS relation, " +
+		"date_trunc('seconds', clock_timestamp() - a.xact_start)::text AS xact_age, " +
+		"CASE WHEN l.pid IS NOT NULL THEN date_trunc('seconds', clock_timestamp() - a.query_start)::text END AS wait_age, " +
+		`regexp_replace(regexp_replace(a.query,E'( |\t)+', ' ', 'g'),E'\n', ' ', 'g') AS query ` +
+		"FROM tree t JOIN pg_stat_activity a ON a.pid = t.pid " +
+		"LEFT JOIN pg_locks l ON l.pid = t.pid AND NOT l.granted " +
+		"ORDER BY t.path"
+)
+
+// SelectLocksTreeQuery returns locks tree query depending on Postgres version.
+func SelectLocksTreeQuery(version int) string {
+	switch {
+	case version < 90600:
+		return PgLocksTreePG95
+	case version < 140000:
+		return PgLocksTreePG13
+	default:
+		return PgLocksTreeDefault
+	}
+}
@@ -0,0 +1,46 @@
+package query
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelectLocksTreeQuery(t *testing.T) {
+	testcases := []struct {
+		version int
+		want    string
+	}{
+		{version: 90500, want: PgLocksTreePG95},
+		{version: 90600, want: PgLocksTreePG13},
+		{version: 130000, want: PgLocksTreePG13},
+		{version: 140000, want: PgLocksTreeDefault},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, SelectLocksTreeQuery(tc.version))
+	}
+}
+
+func Test_LocksTreeQueries(t *testing.T) {
+	versions := []int{90500, 90600, 100000, 110000, 120000, 130000}
+
+	for _, version := range versions {
+		t.Run(fmt.Sprintf("locks/%d", version), func(t *testing.T) {
+			tmpl := SelectLocksTreeQuery(version)
+
+			opts := NewOptions(version, "f", "off", 256)
+			q, err := Format(tmpl, opts)
+			assert.NoError(t, err)
+
+			conn, err := postgres.NewTestConnectVersion(version)
+			assert.NoError(t, err)
+
+			_, err = conn.Exec(q)
+			assert.NoError(t, err)
+
+			conn.Close()
+		})
+	}
+}
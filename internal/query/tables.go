@@ -19,4 +19,25 @@ const (
 		"coalesce(i.tidx_blks_hit, 0) AS tidx_hit " +
 		"FROM pg_stat_{{.ViewType}}_tables t, pg_statio_{{.ViewType}}_tables i " +
 		"WHERE t.relid = i.relid ORDER BY (t.schemaname || '.' || t.relname) DESC"
+
+	// PgStatTableReportQuery defines query used for getting per-table details report based on pg_stat_all_tables,
+	// pg_statio_all_tables and pg_class. Table is specified in 'schema.relname' form.
+	PgStatTableReportQuery = "SELECT s.schemaname || '.' || s.relname AS relation, " +
+		"pg_size_pretty(pg_total_relation_size(s.relid)) AS total_size, " +
+		"pg_size_pretty(pg_relation_size(s.relid)) AS table_size, " +
+		"pg_size_pretty(pg_indexes_size(s.relid)) AS indexes_size, " +
+		"pg_size_pretty(coalesce(pg_total_relation_size(c.reltoastrelid), 0)) AS toast_size, " +
+		"coalesce(s.n_live_tup, 0)::text AS live, coalesce(s.n_dead_tup, 0)::text AS dead, " +
+		"to_char(100 * coalesce(s.n_dead_tup, 0) / greatest(coalesce(s.n_live_tup, 0) + coalesce(s.n_dead_tup, 0), 1), 'FM990.00') AS dead_ratio, " +
+		"coalesce(s.seq_scan, 0)::text AS seq_scan, coalesce(s.seq_tup_read, 0)::text AS seq_read, " +
+		"coalesce(s.idx_scan, 0)::text AS idx_scan, coalesce(s.idx_tup_fetch, 0)::text AS idx_fetch, " +
+		"coalesce(s.n_tup_ins, 0)::text AS inserts, coalesce(s.n_tup_upd, 0)::text AS updates, " +
+		"coalesce(s.n_tup_del, 0)::text AS deletes, coalesce(s.n_tup_hot_upd, 0)::text AS hot_updates, " +
+		"coalesce(i.heap_blks_read, 0)::text AS heap_read, coalesce(i.heap_blks_hit, 0)::text AS heap_hit, " +
+		"coalesce(s.last_vacuum::text, 'never') AS last_vacuum, coalesce(s.last_autovacuum::text, 'never') AS last_autovacuum, " +
+		"coalesce(s.last_analyze::text, 'never') AS last_analyze, coalesce(s.last_autoanalyze::text, 'never') AS last_autoanalyze, " +
+		"s.vacuum_count::text AS vacuum_count, s.autovacuum_count::text AS autovacuum_count, " +
+		"s.analyze_count::text AS analyze_count, s.autoanalyze_count::text AS autoanalyze_count " +
+		"FROM pg_stat_all_tables s JOIN pg_statio_all_tables i ON s.relid = i.relid JOIN pg_class c ON c.oid = s.relid " +
+		"WHERE s.schemaname || '.' || s.relname = $1"
 )
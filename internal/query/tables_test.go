@@ -28,3 +28,19 @@ func Test_StatTablesQueries(t *testing.T) {
 		})
 	}
 }
+
+func Test_StatTableReportQuery(t *testing.T) {
+	versions := []int{90500, 90600, 100000, 110000, 120000, 130000}
+
+	for _, version := range versions {
+		t.Run(fmt.Sprintf("pg_stat_table_report/%d", version), func(t *testing.T) {
+			conn, err := postgres.NewTestConnectVersion(version)
+			assert.NoError(t, err)
+
+			_, err = conn.Exec(PgStatTableReportQuery, "pg_catalog.pg_class")
+			assert.NoError(t, err)
+
+			conn.Close()
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package stat
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ResultDelta defines difference between two results of the same query. It contains rows which are new or have
+// been changed and keys of rows which have been removed. Rows are identified by values of the unique key column.
+type ResultDelta struct {
+	Key     int                /* index of the unique key column */
+	Changed [][]sql.NullString /* new or changed rows */
+	Removed []string           /* unique keys of removed rows */
+}
+
+// NewResultDelta returns difference between previous and current results. Delta can't be made if results have
+// different columns or values of the unique key column are not unique, in such case false is returned.
+func NewResultDelta(prev, curr PGresult, key int) (ResultDelta, bool) {
+	if !prev.Valid || !curr.Valid || key < 0 || key >= curr.Ncols || !equalCols(prev.Cols, curr.Cols) {
+		return ResultDelta{}, false
+	}
+
+	prevRows, ok := rowsByKey(prev, key)
+	if !ok {
+		return ResultDelta{}, false
+	}
+
+	currRows, ok := rowsByKey(curr, key)
+	if !ok {
+		return ResultDelta{}, false
+	}
+
+	d := ResultDelta{Key: key}
+
+	for _, row := range curr.Values {
+		if p, ok := prevRows[row[key].String]; !ok || !equalRows(p, row) {
+			d.Changed = append(d.Changed, row)
+		}
+	}
+
+	for _, row := range prev.Values {
+		if _, ok := currRows[row[key].String]; !ok {
+			d.Removed = append(d.Removed, row[key].String)
+		}
+	}
+
+	return d, true
+}
+
+// Apply applies delta to the previous result and returns the current result. Order of rows is kept, new rows are
+// appended to the end.
+func (d ResultDelta) Apply(prev PGresult) (PGresult, error) {
+	if !prev.Valid || d.Key < 0 || d.Key >= prev.Ncols {
+		return PGresult{}, fmt.Errorf("delta can't be applied to result")
+	}
+
+	changed := make(map[string][]sql.NullString, len(d.Changed))
+	for _, row := range d.Changed {
+		if len(row) != prev.Ncols {
+			return PGresult{}, fmt.Errorf("delta row has %d columns, expected %d", len(row), prev.Ncols)
+		}
+		changed[row[d.Key].String] = row
+	}
+
+	removed := make(map[string]struct{}, len(d.Removed))
+	for _, key := range d.Removed {
+		removed[key] = struct{}{}
+	}
+
+	values := make([][]sql.NullString, 0, len(prev.Values)+len(d.Changed))
+	for _, row := range prev.Values {
+		key := row[d.Key].String
+		if _, ok := removed[key]; ok {
+			continue
+		}
+
+		if c, ok := changed[key]; ok {
+			values = append(values, c)
+			delete(changed, key)
+			continue
+		}
+
+		values = append(values, row)
+	}
+
+	// Rest of changed rows are new rows, append them in the original order.
+	for _, row := range d.Changed {
+		if _, ok := changed[row[d.Key].String]; ok {
+			values = append(values, row)
+		}
+	}
+
+	return PGresult{
+		Values: values,
+		Cols:   prev.Cols,
+		Ncols:  prev.Ncols,
+		Nrows:  len(values),
+		Valid:  true,
+	}, nil
+}
+
+// rowsByKey returns rows of the result mapped by values of the key column. False is returned if values are not unique.
+func rowsByKey(r PGresult, key int) (map[string][]sql.NullString, bool) {
+	rows := make(map[string][]sql.NullString, len(r.Values))
+	for _, row := range r.Values {
+		if len(row) <= key {
+			return nil, false
+		}
+
+		if _, ok := rows[row[key].String]; ok {
+			return nil, false
+		}
+		rows[row[key].String] = row
+	}
+
+	return rows, true
+}
+
+// equalCols returns true if lists of columns are equal.
+func equalCols(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalRows returns true if rows have equal values.
+func equalRows(a, b []sql.NullString) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
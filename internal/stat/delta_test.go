@@ -0,0 +1,62 @@
+package stat
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newDeltaTestResult(rows ...[2]string) PGresult {
+	res := PGresult{Valid: true, Ncols: 2, Nrows: len(rows), Cols: []string{"relname", "seq_scan"}, Values: [][]sql.NullString{}}
+	for _, r := range rows {
+		res.Values = append(res.Values, []sql.NullString{{String: r[0], Valid: true}, {String: r[1], Valid: true}})
+	}
+	return res
+}
+
+func TestNewResultDelta(t *testing.T) {
+	prev := newDeltaTestResult([2]string{"t1", "1"}, [2]string{"t2", "2"}, [2]string{"t3", "3"})
+	curr := newDeltaTestResult([2]string{"t1", "1"}, [2]string{"t3", "4"}, [2]string{"t4", "5"})
+
+	d, ok := NewResultDelta(prev, curr, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 0, d.Key)
+	assert.Equal(t, newDeltaTestResult([2]string{"t3", "4"}, [2]string{"t4", "5"}).Values, d.Changed)
+	assert.Equal(t, []string{"t2"}, d.Removed)
+
+	got, err := d.Apply(prev)
+	assert.NoError(t, err)
+	assert.Equal(t, curr, got)
+
+	// No changes.
+	d, ok = NewResultDelta(curr, curr, 0)
+	assert.True(t, ok)
+	assert.Len(t, d.Changed, 0)
+	assert.Len(t, d.Removed, 0)
+
+	got, err = d.Apply(curr)
+	assert.NoError(t, err)
+	assert.Equal(t, curr, got)
+
+	// Delta can't be made.
+	_, ok = NewResultDelta(prev, newDeltaTestResult([2]string{"t1", "1"}, [2]string{"t1", "2"}), 0)
+	assert.False(t, ok)
+
+	other := curr
+	other.Cols = []string{"relname", "idx_scan"}
+	_, ok = NewResultDelta(prev, other, 0)
+	assert.False(t, ok)
+
+	_, ok = NewResultDelta(PGresult{}, curr, 0)
+	assert.False(t, ok)
+
+	_, ok = NewResultDelta(prev, curr, 2)
+	assert.False(t, ok)
+
+	// Delta can't be applied.
+	_, err = ResultDelta{Key: 0}.Apply(PGresult{})
+	assert.Error(t, err)
+
+	_, err = ResultDelta{Key: 0, Changed: [][]sql.NullString{{{String: "t1", Valid: true}}}}.Apply(prev)
+	assert.Error(t, err)
+}
@@ -236,8 +236,13 @@ func calculateDelta(curr, prev PGresult, itv int, interval [2]int, skey int, des
 		return curr, nil
 	}
 
+	// Columns indexes might be defined by user, check they fit into the result.
+	err := checkColumns(curr.Ncols, interval, skey, ukey)
+	if err != nil {
+		return PGresult{}, err
+	}
+
 	var delta PGresult
-	var err error
 
 	// Diff previous and current stats snapshot
 	if interval != [2]int{0, 0} {
@@ -254,6 +259,24 @@ func calculateDelta(curr, prev PGresult, itv int, interval [2]int, skey int, des
 	return delta, nil
 }
 
+// checkColumns checks indexes of diff interval, sort and unique key columns are within the number of result columns.
+func checkColumns(ncols int, interval [2]int, skey int, ukey int) error {
+	if interval != [2]int{0, 0} {
+		if interval[0] >= ncols || interval[1] >= ncols {
+			return fmt.Errorf("diff interval %v is out of %d columns", interval, ncols)
+		}
+		if ukey < 0 || ukey >= ncols {
+			return fmt.Errorf("unique key %d is out of %d columns", ukey, ncols)
+		}
+	}
+
+	if skey < 0 || skey >= ncols {
+		return fmt.Errorf("order key %d is out of %d columns", skey, ncols)
+	}
+
+	return nil
+}
+
 // diff compares two PGresult values and produces new differential PGresult.
 func diff(curr PGresult, prev PGresult, itv int, interval [2]int, ukey int) (PGresult, error) {
 	var diff PGresult
@@ -206,6 +206,39 @@ func Test_calculateDelta(t *testing.T) {
 	// calculate with invalid input data
 	_, err = calculateDelta(currInvalid, prev, 1, [2]int{1, 3}, 1, true, 0)
 	assert.Error(t, err)
+
+	// calculate with columns indexes out of result columns
+	_, err = calculateDelta(curr, prev, 1, [2]int{1, 3}, 9, true, 0)
+	assert.Error(t, err)
+	_, err = calculateDelta(curr, prev, 1, [2]int{1, 3}, 1, true, 9)
+	assert.Error(t, err)
+	_, err = calculateDelta(curr, prev, 1, [2]int{1, 9}, 1, true, 0)
+	assert.Error(t, err)
+}
+
+func Test_checkColumns(t *testing.T) {
+	testcases := []struct {
+		valid    bool
+		interval [2]int
+		skey     int
+		ukey     int
+	}{
+		{valid: true, interval: [2]int{1, 3}, skey: 3, ukey: 0},
+		{valid: true, interval: [2]int{0, 0}, skey: 1, ukey: 9}, // unique key is not used without diff
+		{valid: false, interval: [2]int{1, 4}, skey: 1, ukey: 0},
+		{valid: false, interval: [2]int{4, 4}, skey: 1, ukey: 0},
+		{valid: false, interval: [2]int{1, 3}, skey: 4, ukey: 0},
+		{valid: false, interval: [2]int{1, 3}, skey: -1, ukey: 0},
+		{valid: false, interval: [2]int{1, 3}, skey: 1, ukey: 4},
+	}
+
+	for _, tc := range testcases {
+		if tc.valid {
+			assert.NoError(t, checkColumns(4, tc.interval, tc.skey, tc.ukey))
+		} else {
+			assert.Error(t, checkColumns(4, tc.interval, tc.skey, tc.ukey))
+		}
+	}
 }
 
 func Test_diff(t *testing.T) {
@@ -0,0 +1,237 @@
+// Stuff related to OS stats of particular processes
+
+package stat
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BackendOSCols defines names of columns with OS stats of backends which are added to activity stats when Postgres
+// is local.
+var BackendOSCols = []string{"cpu%", "rss_kb", "read_b/s", "write_b/s", "pstate"}
+
+// ProcStat describes OS stats of the process based on /proc/<pid>/stat, /proc/<pid>/io and /proc/<pid>/status.
+type ProcStat struct {
+	State                   string  // process state, e.g. R (running), S (sleeping), D (disk sleep)
+	UserTime                float64 // time spent in user mode, in seconds
+	SystemTime              float64 // time spent in kernel mode, in seconds
+	ReadBytes               uint64  // bytes read from storage
+	WriteBytes              uint64  // bytes written to storage
+	IOAvailable             bool    // read/write bytes are available (/proc/<pid>/io requires privileges)
+	RSS                     uint64  // resident set size, in kilobytes
+	VoluntaryCtxSwitches    uint64  // number of voluntary context switches
+	NonvoluntaryCtxSwitches uint64  // number of involuntary context switches
+}
+
+// ProcReader reads OS stats of local processes.
+type ProcReader struct {
+	root  string  // mount point of proc filesystem
+	ticks float64 // value of system setting CLK_TCK
+}
+
+// NewProcReader creates reader of OS stats of local processes.
+func NewProcReader() (*ProcReader, error) {
+	systicks, err := getSysticksLocal()
+	if err != nil {
+		return nil, fmt.Errorf("get systicks failed: %s", err)
+	}
+
+	return &ProcReader{root: "/proc", ticks: systicks}, nil
+}
+
+// Read returns OS stats of the process. Read/write bytes are not returned if they are not available.
+func (r *ProcReader) Read(pid int) (ProcStat, error) {
+	return readProcStatLocal(filepath.Join(r.root, strconv.Itoa(pid)), r.ticks)
+}
+
+// readProcStatLocal returns OS stats of the process read from its proc directory.
+func readProcStatLocal(dir string, ticks float64) (ProcStat, error) {
+	var stat ProcStat
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return stat, err
+	}
+
+	// Process name might contain spaces and parentheses, fields are parsed after the last parenthesis.
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return stat, fmt.Errorf("invalid input, no process name found")
+	}
+
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 13 {
+		return stat, fmt.Errorf("invalid input, too few fields")
+	}
+
+	utime, err := strconv.ParseFloat(fields[11], 64)
+	if err != nil {
+		return stat, err
+	}
+	stime, err := strconv.ParseFloat(fields[12], 64)
+	if err != nil {
+		return stat, err
+	}
+
+	stat.State = fields[0]
+	stat.UserTime, stat.SystemTime = utime/ticks, stime/ticks
+
+	values, err := readKeyValues(filepath.Join(dir, "status"))
+	if err != nil {
+		return stat, err
+	}
+
+	stat.RSS = values["VmRSS"]
+	stat.VoluntaryCtxSwitches = values["voluntary_ctxt_switches"]
+	stat.NonvoluntaryCtxSwitches = values["nonvoluntary_ctxt_switches"]
+
+	// IO stats of processes of other users are not available for unprivileged users.
+	values, err = readKeyValues(filepath.Join(dir, "io"))
+	if err == nil {
+		stat.ReadBytes, stat.WriteBytes = values["read_bytes"], values["write_bytes"]
+		stat.IOAvailable = true
+	}
+
+	return stat, nil
+}
+
+// readKeyValues reads proc file which consists of 'key: value [unit]' lines. Lines with non-numeric values are
+// skipped.
+func readKeyValues(statfile string) (map[string]uint64, error) {
+	f, err := os.Open(filepath.Clean(statfile))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	values := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+
+	return values, scanner.Err()
+}
+
+// readBackendsProcStats returns OS stats of backends listed in activity stats. Backends which OS stats can't be read
+// (e.g. they have exited) are skipped.
+func readBackendsProcStats(r *ProcReader, res PGresult) map[int]ProcStat {
+	stats := map[int]ProcStat{}
+	for _, row := range res.Values {
+		if len(row) == 0 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(row[0].String)
+		if err != nil {
+			continue
+		}
+
+		s, err := r.Read(pid)
+		if err != nil {
+			continue
+		}
+		stats[pid] = s
+	}
+
+	return stats
+}
+
+// appendBackendsProcStats returns activity stats with OS stats of backends inserted before the last (query) column.
+// CPU usage and read/write rates are calculated as deltas between previous and current OS stats taken within the
+// interval (in seconds). Rates are empty when previous stats of the backend are not available.
+func appendBackendsProcStats(res PGresult, curr, prev map[int]ProcStat, interval float64) PGresult {
+	if !res.Valid || res.Ncols == 0 {
+		return res
+	}
+
+	n := res.Ncols - 1
+	cols := make([]string, 0, res.Ncols+len(BackendOSCols))
+	cols = append(cols, res.Cols[:n]...)
+	cols = append(cols, BackendOSCols...)
+	cols = append(cols, res.Cols[n:]...)
+
+	values := make([][]sql.NullString, 0, len(res.Values))
+	for _, row := range res.Values {
+		var osValues []sql.NullString
+		if pid, err := strconv.Atoi(row[0].String); err == nil {
+			osValues = backendProcValues(curr, prev, pid, interval)
+		} else {
+			osValues = make([]sql.NullString, len(BackendOSCols))
+		}
+
+		newrow := make([]sql.NullString, 0, len(row)+len(BackendOSCols))
+		newrow = append(newrow, row[:n]...)
+		newrow = append(newrow, osValues...)
+		newrow = append(newrow, row[n:]...)
+		values = append(values, newrow)
+	}
+
+	return PGresult{
+		Values: values,
+		Cols:   cols,
+		Ncols:  len(cols),
+		Nrows:  len(values),
+		Valid:  true,
+	}
+}
+
+// backendProcValues returns values of OS stats of the backend accordingly to BackendOSCols.
+func backendProcValues(curr, prev map[int]ProcStat, pid int, interval float64) []sql.NullString {
+	values := make([]sql.NullString, len(BackendOSCols))
+
+	c, ok := curr[pid]
+	if !ok {
+		return values
+	}
+
+	values[1] = sql.NullString{String: strconv.FormatUint(c.RSS, 10), Valid: true}
+	values[4] = sql.NullString{String: c.State, Valid: true}
+
+	p, ok := prev[pid]
+	if !ok || interval <= 0 {
+		return values
+	}
+
+	cpu := (c.UserTime + c.SystemTime - p.UserTime - p.SystemTime) / interval * 100
+	if cpu < 0 {
+		cpu = 0
+	}
+	values[0] = sql.NullString{String: strconv.FormatFloat(cpu, 'f', 2, 64), Valid: true}
+
+	// IO stats of processes of other users are not available for unprivileged users.
+	if c.IOAvailable && p.IOAvailable {
+		values[2] = sql.NullString{String: formatRate(c.ReadBytes, p.ReadBytes, interval), Valid: true}
+		values[3] = sql.NullString{String: formatRate(c.WriteBytes, p.WriteBytes, interval), Valid: true}
+	}
+
+	return values
+}
+
+// formatRate returns per-second rate of the counter change within the interval (in seconds).
+func formatRate(curr, prev uint64, interval float64) string {
+	if curr < prev {
+		return "0"
+	}
+	return strconv.FormatUint(uint64(float64(curr-prev)/interval), 10)
+}
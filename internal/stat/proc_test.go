@@ -0,0 +1,109 @@
+package stat
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestProcReader_Read(t *testing.T) {
+	r, err := NewProcReader()
+	assert.NoError(t, err)
+
+	got, err := r.Read(os.Getpid())
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", got.State)
+	assert.Greater(t, got.RSS, uint64(0))
+
+	// Unknown process.
+	_, err = r.Read(-1)
+	assert.Error(t, err)
+}
+
+func Test_readProcStatLocal(t *testing.T) {
+	got, err := readProcStatLocal("testdata/proc/pid", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, ProcStat{
+		State: "R", UserTime: 5.12, SystemTime: 1.28,
+		ReadBytes: 8388608, WriteBytes: 1048576, IOAvailable: true,
+		RSS: 16384, VoluntaryCtxSwitches: 150, NonvoluntaryCtxSwitches: 25,
+	}, got)
+
+	_, err = readProcStatLocal("testdata/proc/unknown", 100)
+	assert.Error(t, err)
+}
+
+func Test_readBackendsProcStats(t *testing.T) {
+	r, err := NewProcReader()
+	assert.NoError(t, err)
+
+	res := PGresult{
+		Valid: true, Ncols: 2, Nrows: 3, Cols: []string{"pid", "query"},
+		Values: [][]sql.NullString{
+			{{String: strconv.Itoa(os.Getpid()), Valid: true}, {String: "SELECT 1", Valid: true}},
+			{{String: "-1", Valid: true}, {String: "SELECT 2", Valid: true}},
+			{{String: "", Valid: false}, {String: "SELECT 3", Valid: true}},
+		},
+	}
+
+	got := readBackendsProcStats(r, res)
+	assert.Len(t, got, 1)
+	assert.Contains(t, got, os.Getpid())
+}
+
+func Test_appendBackendsProcStats(t *testing.T) {
+	res := PGresult{
+		Valid: true, Ncols: 3, Nrows: 3, Cols: []string{"pid", "state", "query"},
+		Values: [][]sql.NullString{
+			{{String: "100", Valid: true}, {String: "active", Valid: true}, {String: "SELECT 1", Valid: true}},
+			{{String: "200", Valid: true}, {String: "idle", Valid: true}, {String: "SELECT 2", Valid: true}},
+			{{String: "300", Valid: true}, {String: "active", Valid: true}, {String: "SELECT 3", Valid: true}},
+		},
+	}
+
+	prev := map[int]ProcStat{
+		100: {State: "R", UserTime: 1, SystemTime: 0.5, ReadBytes: 1000, WriteBytes: 500, IOAvailable: true, RSS: 1024},
+		200: {State: "S", UserTime: 2, SystemTime: 1, RSS: 2048},
+	}
+	curr := map[int]ProcStat{
+		100: {State: "D", UserTime: 2.5, SystemTime: 1, ReadBytes: 5000, WriteBytes: 500, IOAvailable: true, RSS: 4096},
+		200: {State: "S", UserTime: 2, SystemTime: 1, RSS: 2048},
+	}
+
+	got := appendBackendsProcStats(res, curr, prev, 2)
+	assert.Equal(t, PGresult{
+		Valid: true, Ncols: 8, Nrows: 3,
+		Cols: []string{"pid", "state", "cpu%", "rss_kb", "read_b/s", "write_b/s", "pstate", "query"},
+		Values: [][]sql.NullString{
+			{
+				{String: "100", Valid: true}, {String: "active", Valid: true},
+				{String: "100.00", Valid: true}, {String: "4096", Valid: true}, {String: "2000", Valid: true},
+				{String: "0", Valid: true}, {String: "D", Valid: true},
+				{String: "SELECT 1", Valid: true},
+			},
+			{
+				{String: "200", Valid: true}, {String: "idle", Valid: true},
+				{String: "0.00", Valid: true}, {String: "2048", Valid: true}, {}, {}, {String: "S", Valid: true},
+				{String: "SELECT 2", Valid: true},
+			},
+			{
+				{String: "300", Valid: true}, {String: "active", Valid: true},
+				{}, {}, {}, {}, {},
+				{String: "SELECT 3", Valid: true},
+			},
+		},
+	}, got)
+
+	// Without previous stats only RSS and state are available.
+	got = appendBackendsProcStats(res, curr, nil, 2)
+	assert.Equal(t, []sql.NullString{
+		{String: "100", Valid: true}, {String: "active", Valid: true},
+		{}, {String: "4096", Valid: true}, {}, {}, {String: "D", Valid: true},
+		{String: "SELECT 1", Valid: true},
+	}, got.Values[0])
+
+	// Invalid result is returned as-is.
+	assert.Equal(t, PGresult{}, appendBackendsProcStats(PGresult{}, curr, prev, 2))
+}
@@ -59,6 +59,10 @@ type Collector struct {
 	// postgres stats snapshots for previous and current intervals
 	prevPgStat Pgstat
 	currPgStat Pgstat
+	// OS stats of backends and time when they have been read, available when Postgres is local
+	procReader    *ProcReader
+	prevProcStats map[int]ProcStat
+	prevProcTime  time.Time
 }
 
 // Config defines collector's runtime configuration.
@@ -84,18 +88,26 @@ func NewCollector(db *postgres.DB) (*Collector, error) {
 		return nil, fmt.Errorf("read postgres properties failed: %s", err)
 	}
 
-	return &Collector{
+	c := &Collector{
 		config: Config{
 			ticks:              systicks,
 			PostgresProperties: props,
 		},
-	}, nil
+	}
+
+	// OS stats of backends are available when Postgres is local.
+	if db.Local {
+		c.procReader = &ProcReader{root: "/proc", ticks: systicks}
+	}
+
+	return c, nil
 }
 
 // Reset clears stats snapshots.
 func (c *Collector) Reset() {
 	c.prevPgStat = Pgstat{}
 	c.currPgStat = Pgstat{}
+	c.prevProcStats = nil
 }
 
 // Update implements stats collecting.
@@ -168,9 +180,20 @@ func (c *Collector) Update(db *postgres.DB, view view.View, refresh time.Duratio
 
 	s.Pgstat.Activity = pgstat.Activity
 
+	// Extend activity stats with OS stats of backends.
+	if view.Name == "activity" && c.procReader != nil {
+		pgstat.Result = c.appendProcStats(pgstat.Result)
+	}
+
 	c.prevPgStat = c.currPgStat
 	c.currPgStat = pgstat
 
+	// Rows order is meaningful in some views (e.g. locks tree), such views are shown as-is without diffs and sorting.
+	if view.KeepOrder {
+		s.Pgstat.Result = c.currPgStat.Result
+		return s, nil
+	}
+
 	// Compare previous and current Postgres stats snapshots and calculate delta.
 	diff, err := calculateDelta(c.currPgStat.Result, c.prevPgStat.Result, itv, view.DiffIntvl, view.OrderKey, view.OrderDesc, view.UniqueKey)
 	if err != nil {
@@ -182,6 +205,19 @@ func (c *Collector) Update(db *postgres.DB, view view.View, refresh time.Duratio
 	return s, nil
 }
 
+// appendProcStats reads OS stats of backends listed in activity stats and adds them to the stats.
+func (c *Collector) appendProcStats(res PGresult) PGresult {
+	now := time.Now()
+	curr := readBackendsProcStats(c.procReader, res)
+
+	res = appendBackendsProcStats(res, curr, c.prevProcStats, now.Sub(c.prevProcTime).Seconds())
+
+	c.prevProcStats = curr
+	c.prevProcTime = now
+
+	return res
+}
+
 // ToggleCollectExtra toggle collector's setting related to extra stats.
 func (c *Collector) ToggleCollectExtra(e int) {
 	c.config.collectExtra = e
@@ -0,0 +1,152 @@
+package stat
+
+import (
+	"database/sql"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"strconv"
+)
+
+var (
+	// sysstatCols defines names of columns of load average, CPU and memory usage stats.
+	sysstatCols = []string{
+		"load1", "load5", "load15",
+		"us", "sy", "ni", "id", "wa", "hi", "si", "st",
+		"mem_total", "mem_free", "mem_used", "buff_cached",
+		"swap_total", "swap_free", "swap_used", "dirty", "writeback",
+	}
+
+	// diskstatsCols defines names of columns of block devices stats.
+	diskstatsCols = []string{
+		"device", "rrqm/s", "wrqm/s", "r/s", "w/s", "rMB/s", "wMB/s",
+		"avgrq-sz", "avgqu-sz", "await", "r_await", "w_await", "%util",
+	}
+
+	// netdevCols defines names of columns of network interfaces stats.
+	netdevCols = []string{
+		"interface", "rMbps", "wMbps", "rPk/s", "wPk/s", "rAvs", "wAvs",
+		"IErr", "OErr", "Coll", "Sat", "%rUtil", "%wUtil", "%Util",
+	}
+)
+
+// UpdateSystem collects system stats including block devices and network interfaces stats. Stats are read locally
+// from procfs or remotely using pgcenter schema, depending on type of passed DB connection.
+func (c *Collector) UpdateSystem(db *postgres.DB) (System, error) {
+	var s System
+
+	loadavg, err := readLoadAverage(db, c.config.SchemaPgcenterAvail)
+	if err != nil {
+		return s, err
+	}
+
+	s.LoadAvg = loadavg
+
+	meminfo, err := readMeminfo(db, c.config.SchemaPgcenterAvail)
+	if err != nil {
+		return s, err
+	}
+
+	s.Meminfo = meminfo
+
+	cpustat, err := readCpuStat(db, c.config.SchemaPgcenterAvail)
+	if err != nil {
+		return s, err
+	}
+
+	c.prevCpuStat = c.currCpuStat
+	c.currCpuStat = cpustat
+	s.CpuStat = countCpuUsage(c.prevCpuStat, c.currCpuStat, c.config.ticks)
+
+	diskstats, err := c.collectDiskstats(db)
+	if err != nil {
+		return s, err
+	}
+
+	s.Diskstats = diskstats
+
+	netdevs, err := c.collectNetdevs(db)
+	if err != nil {
+		return s, err
+	}
+
+	s.Netdevs = netdevs
+
+	return s, nil
+}
+
+// SystemResults converts system stats into results with 'sysstat', 'diskstats' and 'netdev' names. Inactive block
+// devices and network interfaces are skipped.
+func SystemResults(s System) map[string]PGresult {
+	sysstat := newSystemResult(sysstatCols)
+	sysstat.appendRow(
+		formatFloat(s.LoadAvg.One), formatFloat(s.LoadAvg.Five), formatFloat(s.LoadAvg.Fifteen),
+		formatFloat(s.CpuStat.User), formatFloat(s.CpuStat.Sys), formatFloat(s.CpuStat.Nice), formatFloat(s.CpuStat.Idle),
+		formatFloat(s.CpuStat.Iowait), formatFloat(s.CpuStat.Irq), formatFloat(s.CpuStat.Softirq), formatFloat(s.CpuStat.Steal),
+		formatUint(s.Meminfo.MemTotal), formatUint(s.Meminfo.MemFree), formatUint(s.Meminfo.MemUsed),
+		formatUint(s.Meminfo.MemCached+s.Meminfo.MemBuffers+s.Meminfo.MemSlab),
+		formatUint(s.Meminfo.SwapTotal), formatUint(s.Meminfo.SwapFree), formatUint(s.Meminfo.SwapUsed),
+		formatUint(s.Meminfo.MemDirty), formatUint(s.Meminfo.MemWriteback),
+	)
+
+	diskstats := newSystemResult(diskstatsCols)
+	for _, d := range s.Diskstats {
+		// skip devices which never do IOs
+		if d.Completed == 0 {
+			continue
+		}
+
+		diskstats.appendRow(
+			d.Device,
+			formatFloat(d.Rmerged), formatFloat(d.Wmerged), formatFloat(d.Rcompleted), formatFloat(d.Wcompleted),
+			formatFloat(d.Rsectors), formatFloat(d.Wsectors), formatFloat(d.Arqsz), formatFloat(d.Tweighted),
+			formatFloat(d.Await), formatFloat(d.Rawait), formatFloat(d.Wawait), formatFloat(d.Util),
+		)
+	}
+
+	netdev := newSystemResult(netdevCols)
+	for _, n := range s.Netdevs {
+		// skip interfaces which never seen packets
+		if n.Packets == 0 {
+			continue
+		}
+
+		netdev.appendRow(
+			n.Ifname,
+			formatFloat(n.Rbytes/1024/128), formatFloat(n.Tbytes/1024/128), // conversion to Mbps
+			formatFloat(n.Rpackets), formatFloat(n.Tpackets), formatFloat(n.Raverage), formatFloat(n.Taverage),
+			formatFloat(n.Rerrs), formatFloat(n.Terrs), formatFloat(n.Tcolls),
+			formatFloat(n.Saturation), formatFloat(n.Rutil), formatFloat(n.Tutil), formatFloat(n.Utilization),
+		)
+	}
+
+	return map[string]PGresult{
+		"sysstat":   sysstat,
+		"diskstats": diskstats,
+		"netdev":    netdev,
+	}
+}
+
+// newSystemResult creates empty result with specified columns.
+func newSystemResult(cols []string) PGresult {
+	return PGresult{Valid: true, Ncols: len(cols), Cols: cols}
+}
+
+// appendRow appends row with passed values to the result.
+func (r *PGresult) appendRow(values ...string) {
+	row := make([]sql.NullString, len(values))
+	for i, v := range values {
+		row[i] = sql.NullString{String: v, Valid: true}
+	}
+
+	r.Values = append(r.Values, row)
+	r.Nrows = len(r.Values)
+}
+
+// formatFloat formats float value with two digits precision.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// formatUint formats unsigned integer value.
+func formatUint(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
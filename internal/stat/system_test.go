@@ -0,0 +1,44 @@
+package stat
+
+import (
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSystemResults(t *testing.T) {
+	s := System{
+		LoadAvg: LoadAvg{One: 1.5, Five: 0.75, Fifteen: 0.1},
+		Meminfo: Meminfo{MemTotal: 1024, MemFree: 256, MemUsed: 512, MemCached: 128, MemBuffers: 64, MemSlab: 64},
+		CpuStat: CpuStat{User: 10, Sys: 5, Idle: 85},
+		Diskstats: Diskstats{
+			{Device: "sda", Completed: 100, Rcompleted: 10, Util: 12.345},
+			{Device: "sdb"},
+		},
+		Netdevs: Netdevs{
+			{Ifname: "eth0", Packets: 100, Rbytes: 1024 * 128 * 2},
+			{Ifname: "eth1"},
+		},
+	}
+
+	res := SystemResults(s)
+	views := view.NewSystem()
+	assert.Len(t, res, len(views))
+
+	for name, v := range views {
+		r, ok := res[name]
+		assert.True(t, ok)
+		assert.True(t, r.Valid)
+		assert.Equal(t, v.Ncols, r.Ncols)
+		assert.Len(t, r.Cols, r.Ncols)
+		assert.Equal(t, 1, r.Nrows)
+		assert.Len(t, r.Values[0], r.Ncols)
+	}
+
+	assert.Equal(t, "1.50", res["sysstat"].Values[0][0].String)
+	assert.Equal(t, "256", res["sysstat"].Values[0][14].String)
+	assert.Equal(t, "sda", res["diskstats"].Values[0][0].String)
+	assert.Equal(t, "12.35", res["diskstats"].Values[0][12].String)
+	assert.Equal(t, "eth0", res["netdev"].Values[0][0].String)
+	assert.Equal(t, "2.00", res["netdev"].Values[0][1].String)
+}
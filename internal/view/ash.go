@@ -0,0 +1,19 @@
+package view
+
+import "github.com/lesovsky/pgcenter/internal/query"
+
+// NewASH returns view used for sampling of active sessions (Active Session History).
+func NewASH() Views {
+	return map[string]View{
+		"ash": {
+			Name:       "ash",
+			QueryTmpl:  query.SelectActiveSessions,
+			DiffIntvl:  [2]int{0, 0},
+			Ncols:      8,
+			OrderKey:   0,
+			OrderDesc:  true,
+			ColsWidth:  map[int]int{},
+			MinVersion: 90600,
+		},
+	}
+}
@@ -0,0 +1,151 @@
+package view
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const (
+	// customViewsFilename defines name of the file with user-defined views, the file is looked up in user's config directory.
+	customViewsFilename = "pgcenter/views.yaml"
+)
+
+// customViewNameRE defines allowed names of user-defined views. Names are used in names of files with recorded stats.
+var customViewNameRE = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// customView describes user-defined view in the views file.
+type customView struct {
+	Name        string        `yaml:"name"`          // View name
+	Query       string        `yaml:"query"`         // Query template, formatted using query.Format
+	DiffIntvl   [2]int        `yaml:"diff_interval"` // Columns interval for diff
+	UniqueKey   int           `yaml:"unique_key"`    // Index of column used as unique key
+	OrderKey    int           `yaml:"order_key"`     // Index of column used for order
+	OrderDesc   bool          `yaml:"order_desc"`    // Use descending order
+	Refresh     time.Duration `yaml:"refresh"`       // Refresh interval used when view is shown
+	MinVersion  int           `yaml:"min_version"`   // Minimal Postgres version required for the view
+	Description string        `yaml:"description"`   // Text shown when switching to the view
+}
+
+// customViews describes content of the views file.
+type customViews struct {
+	Views []customView `yaml:"views"`
+}
+
+// DefaultCustomFile returns path to default file with user-defined views.
+func DefaultCustomFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, customViewsFilename)
+}
+
+// LoadCustom reads user-defined views from specified file and adds them to the views. If file is not specified,
+// the default file is used when it exists. Views must not use reserved names.
+func (v Views) LoadCustom(filename string, reserved []string) error {
+	if filename == "" {
+		filename = DefaultCustomFile()
+		if _, err := os.Stat(filename); filename == "" || err != nil {
+			return nil
+		}
+	}
+
+	data, err := ioutil.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return err
+	}
+
+	custom, err := parseCustomViews(data, reserved)
+	if err != nil {
+		return fmt.Errorf("parse %s failed: %s", filename, err)
+	}
+
+	for name, view := range custom {
+		if _, ok := v[name]; ok {
+			return fmt.Errorf("parse %s failed: view '%s' already exists", filename, name)
+		}
+		v[name] = view
+	}
+
+	return nil
+}
+
+// CustomNames returns sorted names of user-defined views.
+func (v Views) CustomNames() []string {
+	var names []string
+	for name, view := range v {
+		if view.Custom {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// parseCustomViews parses and validates views definitions.
+func parseCustomViews(data []byte, reserved []string) (Views, error) {
+	var c customViews
+	err := yaml.UnmarshalStrict(data, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	views := Views{}
+	for _, cv := range c.Views {
+		if !customViewNameRE.MatchString(cv.Name) {
+			return nil, fmt.Errorf("invalid view name '%s', only lowercase letters, digits and underscores allowed", cv.Name)
+		}
+
+		for _, name := range reserved {
+			if cv.Name == name {
+				return nil, fmt.Errorf("view name '%s' is reserved", cv.Name)
+			}
+		}
+
+		if _, ok := views[cv.Name]; ok {
+			return nil, fmt.Errorf("view '%s' defined more than once", cv.Name)
+		}
+
+		if cv.Query == "" {
+			return nil, fmt.Errorf("view '%s': query is not specified", cv.Name)
+		}
+
+		if cv.DiffIntvl[0] < 0 || cv.DiffIntvl[1] < cv.DiffIntvl[0] {
+			return nil, fmt.Errorf("view '%s': invalid diff_interval %v", cv.Name, cv.DiffIntvl)
+		}
+
+		if cv.UniqueKey < 0 || cv.OrderKey < 0 || cv.Refresh < 0 || cv.MinVersion < 0 {
+			return nil, fmt.Errorf("view '%s': negative values are not allowed", cv.Name)
+		}
+
+		msg := cv.Description
+		if msg == "" {
+			msg = fmt.Sprintf("Show %s statistics", cv.Name)
+		}
+
+		views[cv.Name] = View{
+			Name:       cv.Name,
+			QueryTmpl:  cv.Query,
+			DiffIntvl:  cv.DiffIntvl,
+			OrderKey:   cv.OrderKey,
+			OrderDesc:  cv.OrderDesc,
+			UniqueKey:  cv.UniqueKey,
+			ColsWidth:  map[int]int{},
+			Msg:        msg,
+			Filters:    map[int]*regexp.Regexp{},
+			Refresh:    cv.Refresh,
+			MinVersion: cv.MinVersion,
+			Custom:     true,
+		}
+	}
+
+	return views, nil
+}
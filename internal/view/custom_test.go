@@ -0,0 +1,87 @@
+package view
+
+import (
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_parseCustomViews(t *testing.T) {
+	data := []byte(`
+views:
+  - name: queue
+    query: "SELECT queue_name, count(*) AS total FROM {{.ViewType}}_queue GROUP BY queue_name"
+    diff_interval: [1, 1]
+    order_key: 1
+    order_desc: true
+    refresh: 5s
+    min_version: 100000
+    description: "Show queues statistics"
+  - name: ext_stats
+    query: "SELECT * FROM ext_stats"
+`)
+
+	views, err := parseCustomViews(data, nil)
+	assert.NoError(t, err)
+	assert.Len(t, views, 2)
+
+	v := views["queue"]
+	assert.Equal(t, "queue", v.Name)
+	assert.Equal(t, [2]int{1, 1}, v.DiffIntvl)
+	assert.Equal(t, 1, v.OrderKey)
+	assert.True(t, v.OrderDesc)
+	assert.Equal(t, 5*time.Second, v.Refresh)
+	assert.Equal(t, 100000, v.MinVersion)
+	assert.Equal(t, "Show queues statistics", v.Msg)
+	assert.True(t, v.Custom)
+	assert.Equal(t, "Show ext_stats statistics", views["ext_stats"].Msg)
+
+	// Invalid definitions.
+	testcases := []string{
+		"views:\n  - name: Invalid-Name\n    query: SELECT 1",
+		"views:\n  - name: noquery",
+		"views:\n  - name: dup\n    query: SELECT 1\n  - name: dup\n    query: SELECT 1",
+		"views:\n  - name: diff\n    query: SELECT 1\n    diff_interval: [2, 1]",
+		"views:\n  - name: order\n    query: SELECT 1\n    order_key: -1",
+		"views:\n  - name: unknown\n    query: SELECT 1\n    unknown_field: 1",
+		"invalid",
+	}
+
+	for _, tc := range testcases {
+		_, err := parseCustomViews([]byte(tc), nil)
+		assert.Error(t, err)
+	}
+
+	// Reserved names.
+	_, err = parseCustomViews([]byte("views:\n  - name: meta\n    query: SELECT 1"), []string{"gap", "meta"})
+	assert.Error(t, err)
+}
+
+func TestViews_LoadCustom(t *testing.T) {
+	f, err := ioutil.TempFile("", "pgcenter-views-*.yaml")
+	assert.NoError(t, err)
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	_, err = f.WriteString("views:\n  - name: queue\n    query: SELECT 1\n    min_version: 120000\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	views := New()
+	assert.NoError(t, views.LoadCustom(f.Name(), nil))
+	assert.Equal(t, []string{"queue"}, views.CustomNames())
+
+	// Custom views are removed if they are not supported by Postgres.
+	assert.NoError(t, views.Configure(query.NewOptions(110000, "f", "off", 256)))
+	assert.NotContains(t, views, "queue")
+
+	// Views must not override existing views.
+	views = New()
+	assert.NoError(t, views.LoadCustom(f.Name(), nil))
+	assert.Error(t, views.LoadCustom(f.Name(), nil))
+
+	// Missing file.
+	assert.Error(t, New().LoadCustom("/nonexistent", nil))
+}
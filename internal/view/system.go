@@ -0,0 +1,40 @@
+package view
+
+import "regexp"
+
+// NewSystem returns set of views for system stats. These views have no queries, stats are collected by stats
+// collector and used only when recording stats and making reports.
+func NewSystem() Views {
+	return map[string]View{
+		"sysstat": {
+			Name:      "sysstat",
+			DiffIntvl: [2]int{0, 0},
+			Ncols:     20,
+			OrderKey:  0,
+			OrderDesc: true,
+			ColsWidth: map[int]int{},
+			Msg:       "Show load average, CPU and memory usage statistics",
+			Filters:   map[int]*regexp.Regexp{},
+		},
+		"diskstats": {
+			Name:      "diskstats",
+			DiffIntvl: [2]int{0, 0},
+			Ncols:     13,
+			OrderKey:  0,
+			OrderDesc: false,
+			ColsWidth: map[int]int{},
+			Msg:       "Show block devices statistics",
+			Filters:   map[int]*regexp.Regexp{},
+		},
+		"netdev": {
+			Name:      "netdev",
+			DiffIntvl: [2]int{0, 0},
+			Ncols:     14,
+			OrderKey:  0,
+			OrderDesc: false,
+			ColsWidth: map[int]int{},
+			Msg:       "Show network interfaces statistics",
+			Filters:   map[int]*regexp.Regexp{},
+		},
+	}
+}
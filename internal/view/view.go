@@ -8,21 +8,24 @@ import (
 
 // View describes how stats received from Postgres should be displayed.
 type View struct {
-	Name      string                 // View name
-	QueryTmpl string                 // Query template used for making particular query.
-	Query     string                 // Query based on template and runtime options.
-	DiffIntvl [2]int                 // Columns interval for diff
-	Cols      []string               // Columns names
-	Ncols     int                    // Number of columns returned by query, used as a right border for OrderKey
-	OrderKey  int                    // Index of column used for order
-	OrderDesc bool                   // Order direction: descending (true) or ascending (false)
-	UniqueKey int                    // index of column used as unique key when comparing rows during diffs, by default it's zero which is OK in almost all views
-	ColsWidth map[int]int            // Width used for columns and control an aligning
-	Aligned   bool                   // Flag shows aligning is calculated or not
-	Msg       string                 // Show this text in Cmdline when switching to this view
-	Filters   map[int]*regexp.Regexp // Filter patterns: key is the column index, value - regexp pattern
-	Refresh   time.Duration          // Number of seconds between update view.
-	ShowExtra int                    // Specifies extra stats should be enabled on the view.
+	Name       string                 // View name
+	QueryTmpl  string                 // Query template used for making particular query.
+	Query      string                 // Query based on template and runtime options.
+	DiffIntvl  [2]int                 // Columns interval for diff
+	Cols       []string               // Columns names
+	Ncols      int                    // Number of columns returned by query, used as a right border for OrderKey
+	OrderKey   int                    // Index of column used for order
+	OrderDesc  bool                   // Order direction: descending (true) or ascending (false)
+	UniqueKey  int                    // index of column used as unique key when comparing rows during diffs, by default it's zero which is OK in almost all views
+	KeepOrder  bool                   // Keep rows order returned by query and don't sort rows, used in views where rows order is meaningful
+	ColsWidth  map[int]int            // Width used for columns and control an aligning
+	Aligned    bool                   // Flag shows aligning is calculated or not
+	Msg        string                 // Show this text in Cmdline when switching to this view
+	Filters    map[int]*regexp.Regexp // Filter patterns: key is the column index, value - regexp pattern
+	Refresh    time.Duration          // Number of seconds between update view.
+	ShowExtra  int                    // Specifies extra stats should be enabled on the view.
+	MinVersion int                    // Minimal Postgres version required for the view, zero means any version
+	Custom     bool                   // View is defined by user in views file
 }
 
 // Views is a list of all used context units.
@@ -168,6 +171,18 @@ func New() Views {
 			Msg:       "Show statements temp tables statistics (local IO)",
 			Filters:   map[int]*regexp.Regexp{},
 		},
+		"locks": {
+			Name:      "locks",
+			QueryTmpl: query.PgLocksTreeDefault,
+			DiffIntvl: [2]int{0, 0},
+			Ncols:     10,
+			OrderKey:  0,
+			OrderDesc: false,
+			KeepOrder: true,
+			ColsWidth: map[int]int{},
+			Msg:       "Show locks tree (blocking chains)",
+			Filters:   map[int]*regexp.Regexp{},
+		},
 		"progress_vacuum": {
 			Name:      "progress_vacuum",
 			QueryTmpl: query.PgStatProgressVacuumDefault,
@@ -226,6 +241,16 @@ func (v Views) Configure(opts query.Options) error {
 		case "statements_timings":
 			view.QueryTmpl = query.SelectStatStatementsTimingQuery(opts.Version)
 			v[k] = view
+		case "locks":
+			view.QueryTmpl = query.SelectLocksTreeQuery(opts.Version)
+			v[k] = view
+		}
+	}
+
+	// Remove views which are not supported by Postgres.
+	for k, view := range v {
+		if view.MinVersion > 0 && opts.Version < view.MinVersion {
+			delete(v, k)
 		}
 	}
 
@@ -8,7 +8,7 @@ import (
 
 func TestNew(t *testing.T) {
 	v := New()
-	assert.Equal(t, 15, len(v)) // 15 is the total number of views have to be returned
+	assert.Equal(t, 16, len(v)) // 16 is the total number of views have to be returned
 }
 
 func TestViews_Configure(t *testing.T) {
@@ -119,6 +119,7 @@ func TestViews_Configure(t *testing.T) {
 		case 90500:
 			assert.Equal(t, query.PgStatActivity95, views["activity"].QueryTmpl)
 			assert.Equal(t, 12, views["activity"].Ncols)
+			assert.Equal(t, query.PgLocksTreePG95, views["locks"].QueryTmpl)
 		}
 
 		for _, v := range views {
@@ -0,0 +1,353 @@
+package profile
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter defines backends which are profiled when PID of the backend is not specified. Empty fields are not used
+// for filtering.
+type Filter struct {
+	Database string         // name of the database backends are connected to
+	User     string         // name of the user logged into backends
+	AppName  string         // application_name of backends
+	Query    *regexp.Regexp // regexp which queries of backends should match
+	QueryID  string         // query_id of queries executed by backends, Postgres 14 and newer
+}
+
+// IsEmpty returns true if no filter is specified.
+func (f Filter) IsEmpty() bool {
+	return f.Database == "" && f.User == "" && f.AppName == "" && f.Query == nil && f.QueryID == ""
+}
+
+// String returns description of the filter.
+func (f Filter) String() string {
+	var s []string
+	if f.Database != "" {
+		s = append(s, "database="+f.Database)
+	}
+	if f.User != "" {
+		s = append(s, "user="+f.User)
+	}
+	if f.AppName != "" {
+		s = append(s, "application="+f.AppName)
+	}
+	if f.Query != nil {
+		s = append(s, "query~"+f.Query.String())
+	}
+	if f.QueryID != "" {
+		s = append(s, "query_id="+f.QueryID)
+	}
+	return strings.Join(s, ", ")
+}
+
+// queryProfile defines wait events durations of the query aggregated across all its executions on all profiled
+// backends.
+type queryProfile struct {
+	query      string             // text of the query
+	queryID    string             // query_id of the query, empty if it is not available
+	executions int                // number of observed executions of the query
+	durations  map[string]float64 // durations of wait events
+	samples    map[string]int     // number of samples of wait events
+	os         osStats            // OS stats of backends, accounted when Postgres is local
+}
+
+// total returns total duration of the query executions.
+func (q *queryProfile) total() float64 {
+	var total float64
+	for _, v := range q.durations {
+		total += v
+	}
+	return total
+}
+
+// stats returns wait events durations and their percent ratios accordingly to total duration of the query.
+func (q *queryProfile) stats() stats {
+	s := newStatsStore()
+	total := q.total()
+	for k, v := range q.durations {
+		s.durations[k] = v
+		if total > 0 {
+			s.ratios[k] = (100 * v) / total
+		}
+	}
+	s.os = q.os
+	return s
+}
+
+// profiles aggregates wait events durations of queries executed by profiled backends. Queries are identified by
+// query_id if it is available, or by query text.
+type profiles struct {
+	backends map[int]profileStat      // the latest snapshots of backends which are executing queries
+	procs    map[int]stat.ProcStat    // the latest OS stats snapshots of backends
+	queries  map[string]*queryProfile // aggregated profiles of queries
+}
+
+// newProfiles creates new storage of queries profiles.
+func newProfiles() *profiles {
+	return &profiles{
+		backends: map[int]profileStat{},
+		procs:    map[int]stat.ProcStat{},
+		queries:  map[string]*queryProfile{},
+	}
+}
+
+// add accounts snapshots of profiled backends and their OS stats (if available). Backends missing in snapshots are
+// considered as finished their queries.
+func (p *profiles) add(snapshots map[int]profileStat, procs map[int]stat.ProcStat) {
+	for pid, curr := range snapshots {
+		prev := p.backends[pid]
+
+		var q *queryProfile
+		switch {
+		case prev.state != "active" && curr.state == "active":
+			// !active -> active - a query has been started.
+			q = p.count(curr, profileStat{}, true)
+		case prev.state == "active" && curr.state == "active" && prev.changeStateTime == curr.changeStateTime:
+			// active -> active - query continues executing.
+			q = p.count(curr, prev, false)
+		case prev.state == "active" && curr.state == "active":
+			// active -> active (new) - a new query has been started.
+			q = p.count(curr, profileStat{}, true)
+		}
+
+		// Account OS stats changed since the previous snapshot.
+		if q != nil {
+			prevProc, ok1 := p.procs[pid]
+			currProc, ok2 := procs[pid]
+			if ok1 && ok2 {
+				q.os.add(prevProc, currProc)
+			}
+		}
+
+		if curr.state == "active" {
+			p.backends[pid] = curr
+		} else {
+			delete(p.backends, pid)
+		}
+	}
+
+	for pid := range p.backends {
+		if _, ok := snapshots[pid]; !ok {
+			delete(p.backends, pid)
+		}
+	}
+
+	p.procs = procs
+}
+
+// count accounts time passed since the previous snapshot of the backend to its current wait event. Returns profile of
+// the query.
+func (p *profiles) count(curr profileStat, prev profileStat, started bool) *queryProfile {
+	key := curr.queryID
+	if key == "" {
+		key = curr.queryText
+	}
+
+	q, ok := p.queries[key]
+	if !ok {
+		q = &queryProfile{
+			query: curr.queryText, queryID: curr.queryID, durations: map[string]float64{}, samples: map[string]int{},
+		}
+		p.queries[key] = q
+	}
+
+	if started {
+		q.executions++
+	}
+
+	entry := curr.waitEntry
+	if entry == "" {
+		entry = "Running"
+	}
+	q.durations[entry] += curr.queryDurationSec - prev.queryDurationSec
+	q.samples[entry]++
+
+	return q
+}
+
+// sorted returns profiles of queries sorted by their total duration.
+func (p *profiles) sorted() []*queryProfile {
+	list := make([]*queryProfile, 0, len(p.queries))
+	for _, q := range p.queries {
+		list = append(list, q)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		ti, tj := list[i].total(), list[j].total()
+		if ti != tj {
+			return ti > tj
+		}
+		return list[i].query < list[j].query
+	})
+
+	return list
+}
+
+// profileBackends profiles all backends matching the filter (or the single backend if PID is specified), and writes
+// profiles of queries aggregated across backends when profiling is stopped. Profiling of the single backend is
+// stopped when the backend exits.
+func profileBackends(w io.Writer, conn *postgres.DB, cfg Config, doQuit chan os.Signal) error {
+	// Don't mix log messages with machine-readable output.
+	logw := w
+	if cfg.Format != "" && cfg.Format != FormatText {
+		logw = os.Stderr
+	}
+
+	var version int
+	err := conn.QueryRow("SELECT current_setting('server_version_num')::int").Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	query, err := newBackendsQuery(version, cfg.Filter)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Pid != 0 {
+		_, err = fmt.Fprintf(logw, "LOG: Profiling process %d with %s sampling\n", cfg.Pid, cfg.Frequency)
+	} else {
+		_, err = fmt.Fprintf(logw, "LOG: Profiling backends matching %s with %s sampling\n", cfg.Filter, cfg.Frequency)
+	}
+	if err != nil {
+		return err
+	}
+
+	// OS stats of backends are available when Postgres is local.
+	var reader *stat.ProcReader
+	if conn.Local {
+		reader, err = stat.NewProcReader()
+		if err != nil {
+			return err
+		}
+	}
+
+	p := newProfiles()
+	t := time.NewTicker(cfg.Frequency)
+
+	for {
+		snapshots, err := getBackendsSnapshot(conn, query, cfg.Filter, cfg.Pid)
+		if err != nil {
+			t.Stop()
+			return err
+		}
+
+		pids := make([]int, 0, len(snapshots))
+		for pid := range snapshots {
+			pids = append(pids, pid)
+		}
+
+		p.add(snapshots, readProcStats(reader, pids))
+
+		if cfg.Pid != 0 && len(snapshots) == 0 {
+			t.Stop()
+			err := writeProfiles(w, p, cfg)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(logw, "LOG: Stop profiling, process with pid %d doesn't exist\n", cfg.Pid)
+			return err
+		}
+
+		// Wait ticker ticks.
+		select {
+		case <-t.C:
+			continue
+		case <-doQuit:
+			t.Stop()
+			err := writeProfiles(w, p, cfg)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("got interrupt")
+		}
+	}
+}
+
+// newBackendsQuery returns query for getting snapshots of backends matching the filter.
+func newBackendsQuery(version int, f Filter) (string, error) {
+	queryID := "''"
+	if version >= 140000 {
+		queryID = "coalesce(query_id::text, '')"
+	} else if f.QueryID != "" {
+		return "", fmt.Errorf("filtering by query_id requires Postgres 14 or newer")
+	}
+
+	query := "SELECT pid, " +
+		"coalesce(extract(epoch from clock_timestamp() - query_start), 0) AS query_duration, " +
+		"coalesce(date_trunc('milliseconds', state_change)::text, '') AS state_change_time, " +
+		"coalesce(state, '') AS state, " +
+		"coalesce(wait_event_type ||'.'|| wait_event, '') AS wait_entry, " +
+		"coalesce(query, '') AS query, " +
+		queryID + " AS query_id " +
+		"FROM pg_stat_activity WHERE pid <> pg_backend_pid() " +
+		"AND ($1::text = '' OR datname = $1::text) AND ($2::text = '' OR usename = $2::text) " +
+		"AND ($3::text = '' OR application_name = $3::text) AND ($4::text = '' OR " + queryID + " = $4::text) " +
+		"AND ($5::int = 0 OR pid = $5::int)"
+
+	if version >= 100000 {
+		query += " AND backend_type = 'client backend'"
+	}
+
+	return query + " /* pgcenter profile */", nil
+}
+
+// getBackendsSnapshot gets snapshots of backends matching the filter, or snapshot of the single backend if PID is
+// specified.
+func getBackendsSnapshot(conn *postgres.DB, query string, f Filter, pid int) (map[int]profileStat, error) {
+	rows, err := conn.Query(query, f.Database, f.User, f.AppName, f.QueryID, pid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := map[int]profileStat{}
+	for rows.Next() {
+		var pid int
+		var s profileStat
+
+		err := rows.Scan(&pid, &s.queryDurationSec, &s.changeStateTime, &s.state, &s.waitEntry, &s.queryText, &s.queryID)
+		if err != nil {
+			return nil, err
+		}
+
+		if f.Query != nil && !f.Query.MatchString(s.queryText) {
+			continue
+		}
+
+		snapshots[pid] = s
+	}
+
+	return snapshots, rows.Err()
+}
+
+// printProfiles prints wait events durations of profiled queries, queries with the longest total duration first.
+func printProfiles(w io.Writer, p *profiles, strsize int) error {
+	for _, q := range p.sorted() {
+		_, err := fmt.Fprintf(w, "LOG: %d executions, %.6f seconds total\n", q.executions, q.total())
+		if err != nil {
+			return err
+		}
+
+		err = printHeader(w, profileStat{queryText: q.query}, strsize)
+		if err != nil {
+			return err
+		}
+
+		err = printStat(w, q.stats())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
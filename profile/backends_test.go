@@ -0,0 +1,110 @@
+package profile
+
+import (
+	"bytes"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	assert.True(t, Filter{}.IsEmpty())
+
+	f := Filter{Database: "shop", User: "alice", Query: regexp.MustCompile("^UPDATE")}
+	assert.False(t, f.IsEmpty())
+	assert.Equal(t, "database=shop, user=alice, query~^UPDATE", f.String())
+}
+
+func Test_profiles_add(t *testing.T) {
+	p := newProfiles()
+
+	// Two backends start executing queries.
+	p.add(map[int]profileStat{
+		1: {queryDurationSec: 0.5, changeStateTime: "t1", state: "active", waitEntry: "IO.DataFileRead", queryText: "UPDATE t1", queryID: "100"},
+		2: {queryDurationSec: 1.0, changeStateTime: "t1", state: "active", queryText: "UPDATE t1", queryID: "100"},
+	}, nil)
+
+	// First backend continues, second one finished and started another query.
+	p.add(map[int]profileStat{
+		1: {queryDurationSec: 1.5, changeStateTime: "t1", state: "active", waitEntry: "Lock.transactionid", queryText: "UPDATE t1", queryID: "100"},
+		2: {queryDurationSec: 0.25, changeStateTime: "t2", state: "active", queryText: "SELECT 1", queryID: "200"},
+	}, nil)
+
+	// First backend is idle, second one is gone.
+	p.add(map[int]profileStat{
+		1: {queryDurationSec: 2.0, changeStateTime: "t3", state: "idle", queryText: "UPDATE t1", queryID: "100"},
+	}, nil)
+	assert.Len(t, p.backends, 0)
+
+	// The same query is started again.
+	p.add(map[int]profileStat{
+		1: {queryDurationSec: 0.1, changeStateTime: "t4", state: "active", waitEntry: "IO.DataFileRead", queryText: "UPDATE t1", queryID: "100"},
+	}, nil)
+
+	assert.Len(t, p.queries, 2)
+
+	q := p.queries["100"]
+	assert.Equal(t, 3, q.executions)
+	assert.InDelta(t, 0.6, q.durations["IO.DataFileRead"], 0.0001)
+	assert.InDelta(t, 1.0, q.durations["Lock.transactionid"], 0.0001)
+	assert.InDelta(t, 1.0, q.durations["Running"], 0.0001)
+	assert.InDelta(t, 2.6, q.total(), 0.0001)
+	assert.Equal(t, map[string]int{"IO.DataFileRead": 2, "Lock.transactionid": 1, "Running": 1}, q.samples)
+	assert.InDelta(t, 0.6/2.6*100, q.stats().ratios["IO.DataFileRead"], 0.0001)
+
+	list := p.sorted()
+	assert.Equal(t, "UPDATE t1", list[0].query)
+	assert.Equal(t, "SELECT 1", list[1].query)
+}
+
+func Test_profiles_add_os(t *testing.T) {
+	p := newProfiles()
+
+	// OS stats are accounted since the second snapshot of the backend.
+	p.add(map[int]profileStat{
+		1: {queryDurationSec: 0.5, changeStateTime: "t1", state: "active", queryText: "UPDATE t1"},
+	}, map[int]stat.ProcStat{
+		1: {State: "R", UserTime: 1.0, SystemTime: 0.5, VoluntaryCtxSwitches: 10},
+	})
+	p.add(map[int]profileStat{
+		1: {queryDurationSec: 1.0, changeStateTime: "t1", state: "active", queryText: "UPDATE t1"},
+	}, map[int]stat.ProcStat{
+		1: {State: "R", UserTime: 1.3, SystemTime: 0.6, VoluntaryCtxSwitches: 15},
+	})
+
+	q := p.queries["UPDATE t1"]
+	assert.True(t, q.os.available)
+	assert.InDelta(t, 0.3, q.os.userTime, 0.0001)
+	assert.InDelta(t, 0.1, q.os.systemTime, 0.0001)
+	assert.Equal(t, uint64(5), q.os.voluntaryCtxSwitches)
+	assert.False(t, q.os.ioAvailable)
+	assert.Equal(t, q.os, q.stats().os)
+}
+
+func Test_newBackendsQuery(t *testing.T) {
+	q, err := newBackendsQuery(140000, Filter{QueryID: "100"})
+	assert.NoError(t, err)
+	assert.Contains(t, q, "query_id::text")
+	assert.Contains(t, q, "backend_type = 'client backend'")
+
+	q, err = newBackendsQuery(90600, Filter{Database: "shop"})
+	assert.NoError(t, err)
+	assert.NotContains(t, q, "query_id::text")
+	assert.NotContains(t, q, "backend_type")
+
+	_, err = newBackendsQuery(130000, Filter{QueryID: "100"})
+	assert.Error(t, err)
+}
+
+func Test_printProfiles(t *testing.T) {
+	p := newProfiles()
+	p.queries["1"] = &queryProfile{query: "SELECT 1", executions: 2, durations: map[string]float64{"Running": 1.5, "IO.DataFileRead": 0.5}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printProfiles(&buf, p, 64))
+	assert.Contains(t, buf.String(), "LOG: 2 executions, 2.000000 seconds total")
+	assert.Contains(t, buf.String(), "query: SELECT 1")
+	assert.Contains(t, buf.String(), " 75.00     1.500000 Running")
+	assert.Contains(t, buf.String(), " 25.00     0.500000 IO.DataFileRead")
+}
@@ -0,0 +1,173 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// FormatText defines human-readable output format, profiles are printed as tables.
+	FormatText = "text"
+	// FormatJSON defines output format where profiles are written as JSON document.
+	FormatJSON = "json"
+	// FormatFolded defines output format where profiles are written as folded stacks accepted by flame graph tools.
+	FormatFolded = "folded"
+)
+
+// jsonProfile defines profile of the query written in JSON format.
+type jsonProfile struct {
+	Query        string          `json:"query"`
+	QueryID      string          `json:"query_id,omitempty"`
+	Executions   int             `json:"executions"`
+	TotalSeconds float64         `json:"total_seconds"`
+	WaitEvents   []jsonWaitEvent `json:"wait_events"`
+	OS           *jsonOSStats    `json:"os,omitempty"`
+}
+
+// jsonOSStats defines OS stats of the query profile written in JSON format. Read/write bytes are omitted if they
+// are not available.
+type jsonOSStats struct {
+	UserSeconds             float64 `json:"user_seconds"`
+	SystemSeconds           float64 `json:"system_seconds"`
+	ReadBytes               *uint64 `json:"read_bytes,omitempty"`
+	WriteBytes              *uint64 `json:"write_bytes,omitempty"`
+	VoluntaryCtxSwitches    uint64  `json:"voluntary_ctx_switches"`
+	NonvoluntaryCtxSwitches uint64  `json:"nonvoluntary_ctx_switches"`
+}
+
+// jsonWaitEvent defines wait event of the query profile written in JSON format.
+type jsonWaitEvent struct {
+	WaitEventType string  `json:"wait_event_type"`
+	WaitEvent     string  `json:"wait_event"`
+	Seconds       float64 `json:"seconds"`
+	Pct           float64 `json:"pct"`
+	Samples       int     `json:"samples"`
+}
+
+// writeProfiles writes profiles of queries in requested format.
+func writeProfiles(w io.Writer, p *profiles, cfg Config) error {
+	switch cfg.Format {
+	case FormatJSON:
+		return writeJSON(w, p, cfg)
+	case FormatFolded:
+		return writeFolded(w, p, cfg.Strsize)
+	default:
+		return printProfiles(w, p, cfg.Strsize)
+	}
+}
+
+// writeJSON writes profiles of queries as JSON document.
+func writeJSON(w io.Writer, p *profiles, cfg Config) error {
+	doc := struct {
+		Pid      int           `json:"pid,omitempty"`
+		Filter   string        `json:"filter,omitempty"`
+		Interval string        `json:"sampling_interval"`
+		Queries  []jsonProfile `json:"queries"`
+	}{
+		Pid:      cfg.Pid,
+		Filter:   cfg.Filter.String(),
+		Interval: cfg.Frequency.String(),
+		Queries:  []jsonProfile{},
+	}
+
+	for _, q := range p.sorted() {
+		s := q.stats()
+		jp := jsonProfile{
+			Query:        q.query,
+			QueryID:      q.queryID,
+			Executions:   q.executions,
+			TotalSeconds: q.total(),
+			WaitEvents:   []jsonWaitEvent{},
+		}
+
+		for _, e := range sortedWaitEvents(q.durations) {
+			typ, event := splitWaitEntry(e.waitEventName)
+			jp.WaitEvents = append(jp.WaitEvents, jsonWaitEvent{
+				WaitEventType: typ,
+				WaitEvent:     event,
+				Seconds:       e.waitEventValue,
+				Pct:           s.ratios[e.waitEventName],
+				Samples:       q.samples[e.waitEventName],
+			})
+		}
+
+		if q.os.available {
+			jp.OS = &jsonOSStats{
+				UserSeconds:             q.os.userTime,
+				SystemSeconds:           q.os.systemTime,
+				VoluntaryCtxSwitches:    q.os.voluntaryCtxSwitches,
+				NonvoluntaryCtxSwitches: q.os.nonvoluntaryCtxSwitches,
+			}
+			if q.os.ioAvailable {
+				readBytes, writeBytes := q.os.readBytes, q.os.writeBytes
+				jp.OS.ReadBytes, jp.OS.WriteBytes = &readBytes, &writeBytes
+			}
+		}
+
+		doc.Queries = append(doc.Queries, jp)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// writeFolded writes profiles of queries as folded stacks: query, wait event type and wait event separated by
+// semicolons, followed by number of samples. Time when query is running without waiting is written as single frame.
+func writeFolded(w io.Writer, p *profiles, strsize int) error {
+	for _, q := range p.sorted() {
+		query := foldedFrame(truncateQuery(q.query, strsize))
+
+		for _, e := range sortedWaitEvents(q.durations) {
+			stack := query
+			if typ, event := splitWaitEntry(e.waitEventName); event != "" {
+				stack += ";" + foldedFrame(typ) + ";" + foldedFrame(event)
+			} else {
+				stack += ";" + foldedFrame(typ)
+			}
+
+			_, err := fmt.Fprintf(w, "%s %d\n", stack, q.samples[e.waitEventName])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sortedWaitEvents returns wait events sorted by their durations.
+func sortedWaitEvents(durations map[string]float64) waitEvents {
+	p := make(waitEvents, 0, len(durations))
+	for k, v := range durations {
+		p = append(p, waitEvent{k, v})
+	}
+
+	sort.SliceStable(p, func(i, j int) bool {
+		if p[i].waitEventValue != p[j].waitEventValue {
+			return p[i].waitEventValue > p[j].waitEventValue
+		}
+		return p[i].waitEventName < p[j].waitEventName
+	})
+
+	return p
+}
+
+// splitWaitEntry splits wait entry into wait event type and wait event. Entry without type (e.g. 'Running') is
+// returned as type with empty event.
+func splitWaitEntry(entry string) (string, string) {
+	parts := strings.SplitN(entry, ".", 2)
+	if len(parts) < 2 {
+		return entry, ""
+	}
+	return parts[0], parts[1]
+}
+
+// foldedFrame returns name of the stack frame which doesn't contain separators of folded stacks format.
+func foldedFrame(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(s, ";", ",")
+}
@@ -0,0 +1,81 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// newFormatTestProfiles creates profiles of two queries.
+func newFormatTestProfiles() *profiles {
+	p := newProfiles()
+	p.queries["100"] = &queryProfile{
+		query: "UPDATE t1 SET v = 1;", queryID: "100", executions: 2,
+		durations: map[string]float64{"Running": 0.5, "IO.DataFileRead": 1.5},
+		samples:   map[string]int{"Running": 5, "IO.DataFileRead": 15},
+	}
+	p.queries["200"] = &queryProfile{
+		query: "SELECT 1", queryID: "200", executions: 1,
+		durations: map[string]float64{"Running": 0.1},
+		samples:   map[string]int{"Running": 1},
+		os:        osStats{available: true, userTime: 0.1, voluntaryCtxSwitches: 2},
+	}
+	return p
+}
+
+func Test_writeJSON(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := Config{Frequency: 100 * time.Millisecond, Filter: Filter{Database: "shop"}}
+	assert.NoError(t, writeJSON(&buf, newFormatTestProfiles(), cfg))
+
+	var doc struct {
+		Filter   string        `json:"filter"`
+		Interval string        `json:"sampling_interval"`
+		Queries  []jsonProfile `json:"queries"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "database=shop", doc.Filter)
+	assert.Equal(t, "100ms", doc.Interval)
+	assert.Len(t, doc.Queries, 2)
+
+	q := doc.Queries[0]
+	assert.Equal(t, "UPDATE t1 SET v = 1;", q.Query)
+	assert.Equal(t, "100", q.QueryID)
+	assert.Equal(t, 2, q.Executions)
+	assert.Equal(t, 2.0, q.TotalSeconds)
+	assert.Equal(t, []jsonWaitEvent{
+		{WaitEventType: "IO", WaitEvent: "DataFileRead", Seconds: 1.5, Pct: 75, Samples: 15},
+		{WaitEventType: "Running", WaitEvent: "", Seconds: 0.5, Pct: 25, Samples: 5},
+	}, q.WaitEvents)
+	assert.Nil(t, q.OS)
+
+	// OS stats without IO stats.
+	assert.Equal(t, &jsonOSStats{UserSeconds: 0.1, VoluntaryCtxSwitches: 2}, doc.Queries[1].OS)
+}
+
+func Test_writeFolded(t *testing.T) {
+	want, err := ioutil.ReadFile("testdata/profile_folded.golden")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeFolded(&buf, newFormatTestProfiles(), 64))
+	assert.Equal(t, string(want), buf.String())
+}
+
+func Test_splitWaitEntry(t *testing.T) {
+	typ, event := splitWaitEntry("IO.DataFileRead")
+	assert.Equal(t, "IO", typ)
+	assert.Equal(t, "DataFileRead", event)
+
+	typ, event = splitWaitEntry("Running")
+	assert.Equal(t, "Running", typ)
+	assert.Equal(t, "", event)
+}
+
+func Test_foldedFrame(t *testing.T) {
+	assert.Equal(t, "SELECT 1, 2 FROM t", foldedFrame("SELECT 1,\n  2 FROM t"))
+	assert.Equal(t, "SELECT 1, SELECT 2", foldedFrame("SELECT 1; SELECT 2"))
+}
@@ -0,0 +1,97 @@
+package profile
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"io"
+)
+
+// osStats defines OS stats of profiled backends accounted during queries execution. Stats are available only when
+// Postgres is running locally.
+type osStats struct {
+	available               bool    // OS stats have been accounted
+	ioAvailable             bool    // read/write bytes have been accounted
+	userTime                float64 // time spent in user mode, in seconds
+	systemTime              float64 // time spent in kernel mode, in seconds
+	readBytes               uint64  // bytes read from storage
+	writeBytes              uint64  // bytes written to storage
+	voluntaryCtxSwitches    uint64  // number of voluntary context switches
+	nonvoluntaryCtxSwitches uint64  // number of involuntary context switches
+}
+
+// add accounts difference between two consecutive OS stats snapshots of the backend.
+func (s *osStats) add(prev, curr stat.ProcStat) {
+	s.available = true
+	s.userTime += positive(curr.UserTime - prev.UserTime)
+	s.systemTime += positive(curr.SystemTime - prev.SystemTime)
+	s.voluntaryCtxSwitches += diffUint(prev.VoluntaryCtxSwitches, curr.VoluntaryCtxSwitches)
+	s.nonvoluntaryCtxSwitches += diffUint(prev.NonvoluntaryCtxSwitches, curr.NonvoluntaryCtxSwitches)
+
+	if prev.IOAvailable && curr.IOAvailable {
+		s.ioAvailable = true
+		s.readBytes += diffUint(prev.ReadBytes, curr.ReadBytes)
+		s.writeBytes += diffUint(prev.WriteBytes, curr.WriteBytes)
+	}
+}
+
+// readProcStats reads OS stats of backends. Backends which stats can't be read (e.g. already exited) are skipped.
+// Returns empty stats if reader is not specified (Postgres is not local).
+func readProcStats(r *stat.ProcReader, pids []int) map[int]stat.ProcStat {
+	procs := map[int]stat.ProcStat{}
+	if r == nil {
+		return procs
+	}
+
+	for _, pid := range pids {
+		if s, err := r.Read(pid); err == nil {
+			procs[pid] = s
+		}
+	}
+
+	return procs
+}
+
+// printOSStat prints OS stats accounted during query execution. CPU time is compared with time when query has been
+// running without wait events, the rest of that time is spent in waitings which are not instrumented by Postgres.
+func printOSStat(w io.Writer, s osStats, running float64) error {
+	cpu := s.userTime + s.systemTime
+	_, err := fmt.Fprintf(w, "cpu: user %.6fs, system %.6fs", s.userTime, s.systemTime)
+	if err != nil {
+		return err
+	}
+
+	if running > 0 {
+		_, err = fmt.Fprintf(w, " (%.2f%% of Running)", 100*cpu/running)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.ioAvailable {
+		_, err = fmt.Fprintf(w, "\nio: read %d bytes, written %d bytes", s.readBytes, s.writeBytes)
+	} else {
+		_, err = fmt.Fprintf(w, "\nio: not available, insufficient privileges")
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "\nctx switches: voluntary %d, involuntary %d\n", s.voluntaryCtxSwitches, s.nonvoluntaryCtxSwitches)
+	return err
+}
+
+// positive returns the value if it is positive, or zero.
+func positive(v float64) float64 {
+	if v > 0 {
+		return v
+	}
+	return 0
+}
+
+// diffUint returns difference between current and previous values of counter, or zero if counter has been reset.
+func diffUint(prev, curr uint64) uint64 {
+	if curr > prev {
+		return curr - prev
+	}
+	return 0
+}
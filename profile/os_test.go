@@ -0,0 +1,53 @@
+package profile
+
+import (
+	"bytes"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_osStats_add(t *testing.T) {
+	var s osStats
+	s.add(
+		stat.ProcStat{State: "R", UserTime: 1.0, SystemTime: 0.5, ReadBytes: 100, WriteBytes: 50, IOAvailable: true, VoluntaryCtxSwitches: 10, NonvoluntaryCtxSwitches: 1},
+		stat.ProcStat{State: "D", UserTime: 1.5, SystemTime: 0.75, ReadBytes: 8292, WriteBytes: 50, IOAvailable: true, VoluntaryCtxSwitches: 20, NonvoluntaryCtxSwitches: 3},
+	)
+	assert.Equal(t, osStats{
+		available: true, ioAvailable: true, userTime: 0.5, systemTime: 0.25, readBytes: 8192, writeBytes: 0,
+		voluntaryCtxSwitches: 10, nonvoluntaryCtxSwitches: 2,
+	}, s)
+
+	// IO stats are not available, counters have been reset (e.g. PID is reused).
+	s = osStats{}
+	s.add(
+		stat.ProcStat{State: "R", UserTime: 2.0, VoluntaryCtxSwitches: 10},
+		stat.ProcStat{State: "R", UserTime: 1.0, VoluntaryCtxSwitches: 5},
+	)
+	assert.Equal(t, osStats{available: true}, s)
+}
+
+func Test_readProcStats(t *testing.T) {
+	assert.Len(t, readProcStats(nil, []int{1}), 0)
+
+	r, err := stat.NewProcReader()
+	assert.NoError(t, err)
+	assert.Len(t, readProcStats(r, []int{1, -1}), 1)
+}
+
+func Test_printOSStat(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, printOSStat(&buf, osStats{
+		available: true, ioAvailable: true, userTime: 1.5, systemTime: 0.5, readBytes: 8192, writeBytes: 4096,
+		voluntaryCtxSwitches: 10, nonvoluntaryCtxSwitches: 2,
+	}, 4))
+	assert.Equal(t, "cpu: user 1.500000s, system 0.500000s (50.00% of Running)\n"+
+		"io: read 8192 bytes, written 4096 bytes\n"+
+		"ctx switches: voluntary 10, involuntary 2\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, printOSStat(&buf, osStats{available: true, userTime: 1}, 0))
+	assert.Equal(t, "cpu: user 1.000000s, system 0.000000s\n"+
+		"io: not available, insufficient privileges\n"+
+		"ctx switches: voluntary 0, involuntary 0\n", buf.String())
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/jackc/pgx/v4"
 	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
 	"io"
 	"os"
 	"os/signal"
@@ -35,13 +36,17 @@ type profileStat struct {
 	state            string  // backend state
 	waitEntry        string  // wait_event_type/wait_event
 	queryText        string  // query executed by backend
+	queryID          string  // query_id of the query, used when backends are profiled by filter
 }
 
 // Config defines program's configuration options.
 type Config struct {
 	Pid       int // PID of profiled backend
 	Frequency time.Duration
-	Strsize   int // Limit length for query string
+	Strsize   int    // Limit length for query string
+	Filter    Filter // Filter of profiled backends, used when PID is not specified
+	Format    string // Output format: text, json or folded
+	Output    string // File where profile is written, stdout is used by default
 }
 
 // RunMain is the main entry point for 'pgcenter profile' command
@@ -53,17 +58,28 @@ func RunMain(dbConfig postgres.Config, config Config) error {
 	}
 	defer conn.Close()
 
+	var w io.Writer = os.Stdout
+	if config.Output != "" {
+		f, err := os.Create(config.Output)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
 	// In case of SIGINT stop program gracefully
 	doQuit := make(chan os.Signal, 1)
 	signal.Notify(doQuit, syscall.SIGINT, syscall.SIGTERM)
 
-	return profileLoop(os.Stdout, conn, config, doQuit)
+	return profileLoop(w, conn, config, doQuit)
 }
 
 // stats defines local statistics storage for profiled query.
 type stats struct {
 	durations map[string]float64
 	ratios    map[string]float64
+	os        osStats // OS stats of the backend, accounted when Postgres is local
 }
 
 // newStatsStore creates new stats store.
@@ -74,11 +90,27 @@ func newStatsStore() stats {
 	}
 }
 
-// profileLoop profiles and prints profiling results.
+// profileLoop profiles and prints profiling results. When PID is not specified, all backends matching the filter
+// are profiled. Machine-readable formats are written when profiling is stopped.
 func profileLoop(w io.Writer, conn *postgres.DB, cfg Config, doQuit chan os.Signal) error {
+	if cfg.Pid == 0 || (cfg.Format != "" && cfg.Format != FormatText) {
+		return profileBackends(w, conn, cfg, doQuit)
+	}
+
 	var prev profileStat
 	s := newStatsStore()
 
+	// OS stats of the backend are available when Postgres is local.
+	var reader *stat.ProcReader
+	if conn.Local {
+		var err error
+		reader, err = stat.NewProcReader()
+		if err != nil {
+			return err
+		}
+	}
+	var prevProc map[int]stat.ProcStat
+
 	_, err := fmt.Fprintf(w, "LOG: Profiling process %d with %s sampling\n", cfg.Pid, cfg.Frequency)
 	if err != nil {
 		return err
@@ -105,6 +137,8 @@ func profileLoop(w io.Writer, conn *postgres.DB, cfg Config, doQuit chan os.Sign
 			return profileErr
 		}
 
+		currProc := readProcStats(reader, []int{cfg.Pid})
+
 		switch {
 		case prev.state != "active" && curr.state == "active":
 			// !active -> active - a query has been started - begin to count stats.
@@ -113,10 +147,12 @@ func profileLoop(w io.Writer, conn *postgres.DB, cfg Config, doQuit chan os.Sign
 				return err
 			}
 			s = countWaitings(s, curr, profileStat{})
+			s = countOS(s, prevProc[cfg.Pid], currProc[cfg.Pid])
 			prev = curr
 		case prev.state == "active" && curr.state == "active" && prev.changeStateTime == curr.changeStateTime:
 			// active -> active - query continues executing - continue to count stats.
 			s = countWaitings(s, curr, prev)
+			s = countOS(s, prevProc[cfg.Pid], currProc[cfg.Pid])
 			prev = curr
 		case prev.state == "active" && curr.state == "active" && prev.changeStateTime != curr.changeStateTime:
 			// active -> active (new) - a new query has been started - print stat for previous query, count new stats.
@@ -126,6 +162,7 @@ func profileLoop(w io.Writer, conn *postgres.DB, cfg Config, doQuit chan os.Sign
 			}
 			s = resetCounters(s)
 			s = countWaitings(s, curr, profileStat{})
+			s = countOS(s, prevProc[cfg.Pid], currProc[cfg.Pid])
 			prev = profileStat{}
 		case prev.state == "active" && curr.state != "active":
 			// active -> idle - query has been finished, but no new query started - print stat, waiting for new query.
@@ -137,6 +174,8 @@ func profileLoop(w io.Writer, conn *postgres.DB, cfg Config, doQuit chan os.Sign
 			prev = profileStat{}
 		}
 
+		prevProc = currProc
+
 		// Wait ticker ticks.
 		select {
 		case <-t.C:
@@ -191,6 +230,17 @@ func countWaitings(s stats, curr profileStat, prev profileStat) stats {
 	return s
 }
 
+// countOS accounts OS stats of the backend changed since the previous snapshot. Stats are not accounted if any of
+// snapshots is not available.
+func countOS(s stats, prev, curr stat.ProcStat) stats {
+	if prev.State == "" || curr.State == "" {
+		return s
+	}
+
+	s.os.add(prev, curr)
+	return s
+}
+
 // Reset stats counters -- delete all entries from the maps
 func resetCounters(s stats) stats {
 	for k := range s.durations {
@@ -199,6 +249,7 @@ func resetCounters(s stats) stats {
 	for k := range s.ratios {
 		delete(s.ratios, k)
 	}
+	s.os = osStats{}
 	return s
 }
 
@@ -258,6 +309,11 @@ func printStat(w io.Writer, s stats) error {
 		return err
 	}
 
+	// Print OS stats if they are available.
+	if s.os.available {
+		return printOSStat(w, s.os, s.durations["Running"])
+	}
+
 	return nil
 }
 
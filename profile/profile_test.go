@@ -6,6 +6,9 @@ import (
 	"github.com/lesovsky/pgcenter/internal/postgres"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"os"
+	"regexp"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -58,6 +61,37 @@ func Test_profileLoop(t *testing.T) {
 	db.Close()
 }
 
+func Test_profileBackends(t *testing.T) {
+	target, err := postgres.NewTestConnect()
+	assert.NoError(t, err)
+
+	db, err := postgres.NewTestConnect()
+	assert.NoError(t, err)
+
+	doQuit := make(chan os.Signal, 1)
+
+	go func() {
+		// waiting for to start profiling
+		time.Sleep(500 * time.Millisecond)
+
+		_, err := target.Exec("SELECT 1, pg_sleep(1)")
+		assert.NoError(t, err)
+
+		doQuit <- syscall.SIGINT
+	}()
+
+	var buf bytes.Buffer
+	cfg := Config{Frequency: 50 * time.Millisecond, Strsize: 64, Filter: Filter{Query: regexp.MustCompile("pg_sleep")}}
+	err = profileLoop(&buf, db, cfg, doQuit)
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "LOG: Profiling backends matching query~pg_sleep with 50ms sampling")
+	assert.Contains(t, buf.String(), "query: SELECT 1, pg_sleep(1)")
+	assert.Contains(t, buf.String(), "Timeout.PgSleep")
+
+	db.Close()
+	target.Close()
+}
+
 func Test_getProfileSnapshot(t *testing.T) {
 	target, err := postgres.NewTestConnect()
 	assert.NoError(t, err)
@@ -0,0 +1,147 @@
+package record
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"strconv"
+	"time"
+)
+
+var (
+	// ashCols defines columns of recorded samples of active sessions. Sample time is recorded as Unix time in
+	// milliseconds. Query texts are recorded separately, once per written batch of samples.
+	ashCols = []string{"sample_time", "pid", "usename", "datname", "state", "wait_event_type", "wait_event", "query_id"}
+	// ashQueriesCols defines columns of recorded query texts.
+	ashQueriesCols = []string{"query_id", "query"}
+)
+
+// ashBuffer accumulates samples of active sessions until they are written into archive.
+type ashBuffer struct {
+	flushEvery int                // number of sampling ticks between writes
+	samples    [][]sql.NullString // accumulated samples
+	queries    map[string]string  // texts of sampled queries by their ids
+}
+
+// newASHBuffer creates buffer of samples which are written once per recording interval.
+func newASHBuffer(sampleInterval, interval time.Duration) *ashBuffer {
+	n := int(interval / sampleInterval)
+	if n < 1 {
+		n = 1
+	}
+
+	return &ashBuffer{flushEvery: n, queries: map[string]string{}}
+}
+
+// add adds sampled sessions to the buffer. Every sampling tick is recorded, even if there are no active sessions
+// (in this case the only row without pid is recorded), hence the number of ticks could be calculated in reports.
+func (b *ashBuffer) add(ts time.Time, res stat.PGresult) error {
+	sampleTime := sql.NullString{String: strconv.FormatInt(ts.UnixNano()/int64(time.Millisecond), 10), Valid: true}
+
+	if res.Nrows == 0 {
+		row := make([]sql.NullString, len(ashCols))
+		row[0] = sampleTime
+		b.samples = append(b.samples, row)
+		return nil
+	}
+
+	// Lookup sampled columns by names, query text is stored separately.
+	idx := make([]int, len(ashCols))
+	for i, name := range ashCols[1:] {
+		n, ok := colIndex(res.Cols, name)
+		if !ok {
+			return fmt.Errorf("column %s not found in sampled sessions", name)
+		}
+		idx[i+1] = n
+	}
+
+	queryIdx, ok := colIndex(res.Cols, "query")
+	if !ok {
+		return fmt.Errorf("column query not found in sampled sessions")
+	}
+
+	for _, values := range res.Values {
+		row := make([]sql.NullString, len(ashCols))
+		row[0] = sampleTime
+		for i := 1; i < len(ashCols); i++ {
+			row[i] = values[idx[i]]
+		}
+		b.samples = append(b.samples, row)
+
+		b.queries[values[idx[len(ashCols)-1]].String] = values[queryIdx].String
+	}
+
+	return nil
+}
+
+// stats returns accumulated samples and query texts as stats ready for writing.
+func (b *ashBuffer) stats() map[string]stat.PGresult {
+	queries := stat.PGresult{Cols: ashQueriesCols, Ncols: len(ashQueriesCols), Valid: true}
+	for id, text := range b.queries {
+		queries.Values = append(queries.Values, []sql.NullString{{String: id, Valid: true}, {String: text, Valid: true}})
+	}
+	queries.Nrows = len(queries.Values)
+
+	return map[string]stat.PGresult{
+		archive.ASHEntryName: {
+			Values: b.samples, Cols: ashCols, Ncols: len(ashCols), Nrows: len(b.samples), Valid: true,
+		},
+		archive.ASHQueriesEntryName: queries,
+	}
+}
+
+// empty returns true if there are no accumulated samples.
+func (b *ashBuffer) empty() bool {
+	return len(b.samples) == 0
+}
+
+// reset removes accumulated samples.
+func (b *ashBuffer) reset() {
+	b.samples = nil
+	b.queries = map[string]string{}
+}
+
+// colIndex returns index of the column with specified name.
+func colIndex(cols []string, name string) (int, bool) {
+	for i, c := range cols {
+		if c == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// sampleASH samples active sessions and writes accumulated samples once per recording interval.
+func (app *app) sampleASH(tick int) error {
+	ts := time.Now()
+
+	res, err := stat.NewPGresult(app.db, app.views[archive.ASHEntryName].Query)
+	if err != nil {
+		return err
+	}
+
+	err = app.ash.add(ts, res)
+	if err != nil {
+		return err
+	}
+
+	if (tick+1)%app.ash.flushEvery != 0 {
+		return nil
+	}
+
+	return app.flushASH()
+}
+
+// flushASH writes accumulated samples of active sessions. Samples are removed from buffer even if writing failed,
+// to avoid unlimited growing of the buffer.
+func (app *app) flushASH() error {
+	if app.ash == nil || app.ash.empty() {
+		return nil
+	}
+
+	stats := app.ash.stats()
+	app.ash.reset()
+
+	return app.store(time.Now(), stats)
+}
@@ -0,0 +1,89 @@
+package record
+
+import (
+	"database/sql"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_newASHBuffer(t *testing.T) {
+	testcases := []struct {
+		sample   time.Duration
+		interval time.Duration
+		want     int
+	}{
+		{sample: 100 * time.Millisecond, interval: time.Second, want: 10},
+		{sample: 100 * time.Millisecond, interval: 10 * time.Second, want: 100},
+		{sample: time.Second, interval: time.Second, want: 1},
+		{sample: 2 * time.Second, interval: time.Second, want: 1},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, newASHBuffer(tc.sample, tc.interval).flushEvery)
+	}
+}
+
+func Test_ashBuffer(t *testing.T) {
+	b := newASHBuffer(100*time.Millisecond, time.Second)
+	assert.True(t, b.empty())
+
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.UTC)
+	cols := []string{"pid", "usename", "datname", "state", "wait_event_type", "wait_event", "query_id", "query"}
+	newRow := func(values ...string) []sql.NullString {
+		row := make([]sql.NullString, len(values))
+		for i, v := range values {
+			row[i] = sql.NullString{String: v, Valid: true}
+		}
+		return row
+	}
+
+	// Two active sessions.
+	assert.NoError(t, b.add(ts, stat.PGresult{
+		Valid: true, Ncols: 8, Nrows: 2, Cols: cols,
+		Values: [][]sql.NullString{
+			newRow("123", "postgres", "pgbench", "active", "IO", "DataFileRead", "1", "select 1"),
+			newRow("456", "postgres", "pgbench", "active", "", "", "2", "select 2"),
+		},
+	}))
+
+	// No active sessions, only the tick is recorded.
+	assert.NoError(t, b.add(ts.Add(100*time.Millisecond), stat.PGresult{Valid: true, Ncols: 8, Cols: cols}))
+
+	// Columns are missing.
+	assert.Error(t, b.add(ts, stat.PGresult{
+		Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"pid"},
+		Values: [][]sql.NullString{newRow("123")},
+	}))
+
+	stats := b.stats()
+	samples := stats[archive.ASHEntryName]
+	assert.Equal(t, ashCols, samples.Cols)
+	assert.Equal(t, 3, samples.Nrows)
+	assert.Equal(t, "1611415860000", samples.Values[0][0].String)
+	assert.Equal(t, "123", samples.Values[0][1].String)
+	assert.Equal(t, "DataFileRead", samples.Values[0][6].String)
+	assert.Equal(t, "1611415860100", samples.Values[2][0].String)
+	assert.False(t, samples.Values[2][1].Valid)
+
+	queries := stats[archive.ASHQueriesEntryName]
+	assert.Equal(t, ashQueriesCols, queries.Cols)
+	assert.Equal(t, 2, queries.Nrows)
+
+	b.reset()
+	assert.True(t, b.empty())
+}
+
+func Test_app_loopCount(t *testing.T) {
+	app := &app{config: Config{Count: 10}}
+	assert.Equal(t, 10, app.loopCount())
+
+	// Count limits number of recording intervals when active sessions are sampled.
+	app.ash = newASHBuffer(100*time.Millisecond, time.Second)
+	assert.Equal(t, 100, app.loopCount())
+
+	app.config.Count = -1
+	assert.Equal(t, -1, app.loopCount())
+}
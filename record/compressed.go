@@ -0,0 +1,203 @@
+package record
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// compressedRecorder implements recorder interface.
+// This implementation works like tarRecorder, but stores only rows changed since the previous snapshot and full
+// snapshots (keyframes) periodically. Every written snapshot is compressed as separate gzip member, hence stats
+// could be appended to the archive. Decompressed archive is a tar stream with no end-of-archive marker.
+type compressedRecorder struct {
+	*tarRecorder
+	gzip             *gzip.Writer
+	views            view.Views               // views used for identifying rows of the stats
+	keyframeInterval int                      // number of snapshots between keyframes
+	snapshots        int                      // number of snapshots written since the last keyframe
+	prev             map[string]stat.PGresult // stats written in the previous snapshot
+	current          string                   // name of the archive where previous snapshot has been written
+}
+
+// newCompressedRecorder creates new compressed recorder.
+func newCompressedRecorder(c tarConfig, views view.Views, keyframeInterval int) recorder {
+	if keyframeInterval < 1 {
+		keyframeInterval = 1
+	}
+
+	return &compressedRecorder{
+		tarRecorder:      newTarRecorder(c).(*tarRecorder),
+		views:            views,
+		keyframeInterval: keyframeInterval,
+		prev:             map[string]stat.PGresult{},
+	}
+}
+
+// open method opens compressed archive.
+func (c *compressedRecorder) open() error {
+	if c.config.rotation.enabled() {
+		err := c.rotate(time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(filepath.Clean(c.filename), c.fileFlags, 0600)
+	if err != nil {
+		return err
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	// Stats can't be appended to archives of other format.
+	if st.Size() > 0 && (c.fileFlags&os.O_TRUNC) == 0 {
+		magic := make([]byte, 2)
+		_, err := f.ReadAt(magic, 0)
+		if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+			_ = f.Close()
+			return fmt.Errorf("%s is not a compressed stats archive, stats can't be appended", c.filename)
+		}
+	}
+
+	// Every snapshot is written as separate gzip member, reading of the archive could be started from its beginning.
+	c.snapshot, err = f.Seek(0, io.SeekEnd)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	fresh := st.Size() == 0 || (c.fileFlags&os.O_TRUNC) != 0
+
+	// Every archive must start with keyframe, which doesn't depend on previous snapshots.
+	if c.filename != c.current || fresh {
+		c.prev = map[string]stat.PGresult{}
+		c.snapshots = 0
+		c.current = c.filename
+	}
+
+	err = c.openIndex(fresh)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	// Every archive starts with metadata.
+	if fresh {
+		c.newMeta = true
+	}
+
+	// Disable O_TRUNC to avoid further archive truncation.
+	c.fileFlags = os.O_CREATE | os.O_RDWR
+
+	c.file = f
+	c.gzip = gzip.NewWriter(c.file)
+	c.writer = tar.NewWriter(c.gzip)
+
+	return nil
+}
+
+// write accepts stats data and writes it into compressed archive. Stats are written as deltas to the previous
+// snapshot, except keyframes and stats which can't be written as delta.
+func (c *compressedRecorder) write(ts time.Time, stats map[string]stat.PGresult) error {
+	keyframe := c.snapshots%c.keyframeInterval == 0
+	c.snapshots++
+
+	err := c.writeMetadata(ts, stats, false)
+	if err != nil {
+		return err
+	}
+
+	for name, res := range stats {
+		var v interface{} = res
+		ext := "json"
+
+		if !keyframe {
+			if d, ok := c.delta(name, res); ok {
+				v, ext = d, "delta"
+			}
+		}
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		// Data of compressed entries can't be read directly, only location of the snapshot is useful.
+		filename := fmt.Sprintf("%s.%s.%s", name, ts.Format("20060102T150405"), ext)
+		err = c.writeEntry(filename, ts, data, false)
+		if err != nil {
+			return err
+		}
+
+		// Gap markers are not related to each other.
+		if name != archive.GapEntryName {
+			c.prev[name] = res
+		}
+	}
+
+	return nil
+}
+
+// delta returns difference between previous and passed stats.
+func (c *compressedRecorder) delta(name string, res stat.PGresult) (stat.ResultDelta, bool) {
+	prev, ok := c.prev[name]
+	if !ok || name == archive.GapEntryName {
+		return stat.ResultDelta{}, false
+	}
+
+	// Samples of active sessions are not repeated in the next snapshots, they are written as-is.
+	if name == archive.ASHEntryName || name == archive.ASHQueriesEntryName {
+		return stat.ResultDelta{}, false
+	}
+
+	var key int
+	if v, ok := c.views[name]; ok {
+		// Rows order is meaningful in some views, such stats are written as-is.
+		if v.KeepOrder {
+			return stat.ResultDelta{}, false
+		}
+		key = v.UniqueKey
+	}
+
+	return stat.NewResultDelta(prev, res, key)
+}
+
+// close finishes the gzip member and closes archive file. End-of-archive marker is not written, hence next
+// snapshots could be appended to the archive. Index is written when the snapshot is written completely.
+func (c *compressedRecorder) close() error {
+	if c.writer != nil {
+		err := c.writer.Flush()
+		if err != nil {
+			fmt.Printf("flushing tar data failed: %s, continue", err)
+		}
+	}
+
+	if c.gzip != nil {
+		err := c.gzip.Close()
+		if err != nil {
+			fmt.Printf("closing gzip stream failed: %s, continue", err)
+		}
+	}
+
+	err := c.file.Close()
+	if err != nil {
+		c.entries = nil // snapshot might not be written, don't index it
+		_ = c.closeIndex()
+		return err
+	}
+
+	return c.closeIndex()
+}
@@ -0,0 +1,96 @@
+package record
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newCompressedTestStats creates stats of 'tables' view with specified number of sequential scans of 't1' table.
+func newCompressedTestStats(n int) map[string]stat.PGresult {
+	return map[string]stat.PGresult{
+		"tables": {
+			Valid: true, Ncols: 2, Nrows: 2, Cols: []string{"relname", "seq_scan"},
+			Values: [][]sql.NullString{
+				{{String: "t1", Valid: true}, {String: fmt.Sprintf("%d", n), Valid: true}},
+				{{String: "t2", Valid: true}, {String: "10", Valid: true}},
+			},
+		},
+	}
+}
+
+func Test_compressedRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-record-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	filename := filepath.Join(dir, "pgcenter.stat.tar.gz")
+	views := view.Views{"tables": {Name: "tables", UniqueKey: 0}}
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Local)
+
+	// Write snapshots using two recorders, the second one appends to the archive.
+	c := newCompressedRecorder(tarConfig{filename: filename}, views, 3)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, c.open())
+		assert.NoError(t, c.write(ts.Add(time.Duration(i)*time.Second), newCompressedTestStats(i)))
+		assert.NoError(t, c.close())
+	}
+
+	c = newCompressedRecorder(tarConfig{filename: filename, append: true}, views, 3)
+	assert.NoError(t, c.open())
+	assert.NoError(t, c.write(ts.Add(4*time.Second), newCompressedTestStats(4)))
+	assert.NoError(t, c.close())
+
+	// Read written entries.
+	f, err := os.Open(filepath.Clean(filename))
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, f.Close()) }()
+
+	gz, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	var names []string
+	var delta stat.ResultDelta
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, hdr.Name)
+
+		if hdr.Name == "tables.20210123T153101.delta" {
+			data, err := ioutil.ReadAll(tr)
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal(data, &delta))
+		}
+	}
+
+	// Keyframes are written every 3 snapshots and at the beginning of appending.
+	assert.Equal(t, []string{
+		"tables.20210123T153100.json", "tables.20210123T153101.delta", "tables.20210123T153102.delta",
+		"tables.20210123T153103.json", "tables.20210123T153104.json",
+	}, names)
+
+	// Only changed row is written into delta.
+	assert.Len(t, delta.Changed, 1)
+	assert.Equal(t, "1", delta.Changed[0][1].String)
+
+	// Stats can't be appended to plain archives.
+	plain := filepath.Join(dir, "pgcenter.stat.tar")
+	assert.NoError(t, ioutil.WriteFile(plain, []byte("example"), 0600))
+	c = newCompressedRecorder(tarConfig{filename: plain, append: true}, views, 3)
+	assert.Error(t, c.open())
+}
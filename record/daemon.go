@@ -0,0 +1,97 @@
+package record
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// maxReconnectBackoff defines maximum interval between reconnection attempts.
+	maxReconnectBackoff = time.Minute
+)
+
+// newGapStats creates stats with gap marker, the marker contains the reason of the gap.
+func newGapStats(reason error) map[string]stat.PGresult {
+	return map[string]stat.PGresult{
+		archive.GapEntryName: {
+			Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"reason"},
+			Values: [][]sql.NullString{{{String: reason.Error(), Valid: true}}},
+		},
+	}
+}
+
+// reconnect checks connection to Postgres and reconnects if connection has been lost. Reconnection is retried
+// with exponential backoff until it succeeded or quit signal is received.
+func (app *app) reconnect(doQuit chan os.Signal) error {
+	if app.db.PQstatus() == nil {
+		return nil
+	}
+
+	backoff := app.config.Interval
+	for {
+		err := postgres.Reconnect(app.db)
+		if err == nil {
+			app.logf("INFO: reconnected to Postgres")
+			return nil
+		}
+
+		app.logf("ERROR: reconnect to Postgres failed: %s, retry in %s", err, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case sig := <-doQuit:
+			return fmt.Errorf("got %s", sig.String())
+		}
+
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+// logf writes timestamped message to the log.
+func (app *app) logf(format string, a ...interface{}) {
+	_, _ = fmt.Fprintf(app.log, "%s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, a...))
+}
+
+// openLog opens log file if it is specified.
+func (app *app) openLog() error {
+	if app.config.LogFile == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(filepath.Clean(app.config.LogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	app.log, app.logFile = f, f
+	return nil
+}
+
+// closeLog closes log file if it is opened.
+func (app *app) closeLog() {
+	if app.logFile == nil {
+		return
+	}
+
+	err := app.logFile.Close()
+	if err != nil {
+		fmt.Printf("close log file failed: %s; ignore", err)
+	}
+
+	app.log, app.logFile = os.Stderr, nil
+}
+
+// reopenLog closes and opens log file again, used when log file has been rotated.
+func (app *app) reopenLog() error {
+	app.closeLog()
+	return app.openLog()
+}
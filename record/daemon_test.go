@@ -0,0 +1,51 @@
+package record
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_newGapStats(t *testing.T) {
+	stats := newGapStats(fmt.Errorf("connection lost"))
+	assert.Len(t, stats, 1)
+
+	res, ok := stats[archive.GapEntryName]
+	assert.True(t, ok)
+	assert.True(t, res.Valid)
+	assert.Equal(t, []string{"reason"}, res.Cols)
+	assert.Equal(t, "connection lost", res.Values[0][0].String)
+}
+
+func Test_app_log(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-record-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	filename := filepath.Join(dir, "record.log")
+
+	app := newApp(Config{LogFile: filename}, postgres.Config{})
+	assert.NoError(t, app.openLog())
+	app.logf("ERROR: first: %s", "example")
+
+	// Rotate log file and reopen it.
+	assert.NoError(t, os.Rename(filename, filename+".1"))
+	assert.NoError(t, app.reopenLog())
+	app.logf("ERROR: second")
+	app.closeLog()
+	assert.Equal(t, os.Stderr, app.log)
+
+	data, err := ioutil.ReadFile(filename + ".1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), " ERROR: first: example\n")
+
+	data, err = ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), " ERROR: second\n")
+	assert.NotContains(t, string(data), "first")
+}
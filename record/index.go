@@ -0,0 +1,59 @@
+package record
+
+import (
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"os"
+	"path/filepath"
+)
+
+// openIndex opens index file of the current archive. When a new archive is started the index is started as well.
+// Index is not written for existing archives which have no index (e.g. recorded by previous versions), because the
+// index must describe the whole archive.
+func (c *tarRecorder) openIndex(fresh bool) error {
+	c.entries = c.entries[:0]
+
+	name := filepath.Clean(archive.IndexName(c.filename))
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+
+	if fresh {
+		flags |= os.O_TRUNC
+	} else if _, err := os.Stat(name); os.IsNotExist(err) {
+		c.index = nil
+		return nil
+	}
+
+	f, err := os.OpenFile(name, flags, 0600)
+	if err != nil {
+		return err
+	}
+
+	c.index = f
+	return nil
+}
+
+// addIndexEntry remembers location of the written entry, entries are written into index when snapshot is written.
+func (c *tarRecorder) addIndexEntry(name string, offset int64, size int64) {
+	if c.index == nil {
+		return
+	}
+
+	c.entries = append(c.entries, archive.IndexEntry{Name: name, Snapshot: c.snapshot, Offset: offset, Size: size})
+}
+
+// closeIndex writes entries of the snapshot into index and closes index file.
+func (c *tarRecorder) closeIndex() error {
+	if c.index == nil {
+		return nil
+	}
+
+	err := archive.WriteIndex(c.index, c.entries)
+	if err != nil {
+		_ = c.index.Close()
+		c.index = nil
+		return err
+	}
+
+	err = c.index.Close()
+	c.index = nil
+	return err
+}
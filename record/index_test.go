@@ -0,0 +1,123 @@
+package record
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_tarRecorder_index(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-record-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	filename := filepath.Join(dir, "pgcenter.stat.tar")
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+
+	newStats := func(v string) map[string]stat.PGresult {
+		return map[string]stat.PGresult{
+			"databases": {
+				Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"datname", "xact_commit"},
+				Values: [][]sql.NullString{{{String: "example", Valid: true}, {String: v, Valid: true}}},
+			},
+		}
+	}
+
+	// Write three snapshots, every snapshot is appended to the archive.
+	tc := newTarRecorder(tarConfig{filename: filename})
+	for i, v := range []string{"100", "200", "400"} {
+		assert.NoError(t, tc.open())
+		assert.NoError(t, tc.write(ts.Add(time.Duration(i)*time.Second), newStats(v)))
+		assert.NoError(t, tc.close())
+	}
+
+	entries, err := archive.ReadIndex(filename)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	// Entries' data could be read directly using offsets from the index.
+	data, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+
+	for i, v := range []string{"100", "200", "400"} {
+		e := entries[i]
+		assert.Equal(t, "databases", e.View)
+		assert.Equal(t, ts.Add(time.Duration(i)*time.Second), e.Ts)
+
+		var res stat.PGresult
+		assert.NoError(t, json.Unmarshal(data[e.Offset:e.Offset+e.Size], &res))
+		assert.Equal(t, v, res.Values[0][1].String)
+	}
+
+	// Snapshots are appended over the end-of-archive marker of the previous snapshot.
+	assert.Equal(t, int64(0), entries[0].Snapshot)
+	assert.Equal(t, entries[0].Offset+512, entries[1].Snapshot) // data fits into single block
+
+	// Index is started over with the archive.
+	tc = newTarRecorder(tarConfig{filename: filename})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(ts, newStats("100")))
+	assert.NoError(t, tc.close())
+
+	entries, err = archive.ReadIndex(filename)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// Index is not written for existing archives without index.
+	assert.NoError(t, os.Remove(archive.IndexName(filename)))
+	tc = newTarRecorder(tarConfig{filename: filename, append: true})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(ts, newStats("100")))
+	assert.NoError(t, tc.close())
+
+	_, err = os.Stat(archive.IndexName(filename))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_compressedRecorder_index(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-record-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	filename := filepath.Join(dir, "pgcenter.stat.tar.gz")
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+	stats := map[string]stat.PGresult{
+		"databases": {
+			Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"datname", "xact_commit"},
+			Values: [][]sql.NullString{{{String: "example", Valid: true}, {String: "100", Valid: true}}},
+		},
+	}
+
+	c := newCompressedRecorder(tarConfig{filename: filename}, nil, 2)
+	var sizes []int64
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, c.open())
+		assert.NoError(t, c.write(ts.Add(time.Duration(i)*time.Second), stats))
+		assert.NoError(t, c.close())
+
+		st, err := os.Stat(filename)
+		assert.NoError(t, err)
+		sizes = append(sizes, st.Size())
+	}
+
+	entries, err := archive.ReadIndex(filename)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	// Entries point to the beginning of snapshots' gzip members.
+	assert.Equal(t, int64(0), entries[0].Snapshot)
+	assert.Equal(t, sizes[0], entries[1].Snapshot)
+	assert.Equal(t, sizes[1], entries[2].Snapshot)
+	assert.Equal(t, "databases.20210123T153101.delta", entries[1].Name)
+
+	for _, e := range entries {
+		assert.Equal(t, int64(-1), e.Offset)
+	}
+}
@@ -0,0 +1,60 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"os"
+	"time"
+)
+
+// newMetadata creates metadata of recording session.
+func newMetadata(version string, props stat.PostgresProperties, opts query.Options) *archive.Metadata {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return &archive.Metadata{
+		Hostname: hostname,
+		Version:  version,
+		Postgres: props,
+		Options:  opts,
+		Views:    map[string][]string{},
+	}
+}
+
+// writeMetadata writes metadata at the beginning of recording session and at the beginning of every archive. Columns
+// of views are taken from recorded stats, hence metadata describes the latest known columns.
+func (c *tarRecorder) writeMetadata(ts time.Time, stats map[string]stat.PGresult, direct bool) error {
+	meta := c.config.meta
+	if meta == nil {
+		return nil
+	}
+
+	for name, res := range stats {
+		if name != archive.GapEntryName && res.Valid {
+			meta.Views[name] = res.Cols
+		}
+	}
+
+	if !c.newMeta {
+		return nil
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("%s.%s.json", archive.MetadataEntryName, ts.Format("20060102T150405"))
+	err = c.writeEntry(filename, ts, data, direct)
+	if err != nil {
+		return err
+	}
+
+	c.newMeta = false
+	return nil
+}
@@ -0,0 +1,82 @@
+package record
+
+import (
+	"archive/tar"
+	"database/sql"
+	"encoding/json"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_tarRecorder_writeMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-record-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	filename := filepath.Join(dir, "pgcenter.stat.tar")
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+	stats := map[string]stat.PGresult{
+		"databases": {
+			Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"datname", "xact_commit"},
+			Values: [][]sql.NullString{{{String: "example", Valid: true}, {String: "100", Valid: true}}},
+		},
+	}
+
+	props := stat.PostgresProperties{VersionNum: 130000, Version: "13.0"}
+	opts := query.NewOptions(props.VersionNum, "f", "off", 0)
+	meta := newMetadata("pgcenter v0.9.0", props, opts)
+
+	// Metadata is written only along with the first snapshot of the session.
+	tc := newTarRecorder(tarConfig{filename: filename, meta: meta})
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, tc.open())
+		assert.NoError(t, tc.write(ts.Add(time.Duration(i)*time.Second), stats))
+		assert.NoError(t, tc.close())
+	}
+
+	// New session writes metadata again.
+	tc = newTarRecorder(tarConfig{filename: filename, append: true, meta: meta})
+	assert.NoError(t, tc.open())
+	assert.NoError(t, tc.write(ts.Add(2*time.Second), stats))
+	assert.NoError(t, tc.close())
+
+	f, err := os.Open(filename)
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, f.Close()) }()
+
+	var names []string
+	var got archive.Metadata
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, hdr.Name)
+
+		if hdr.Name == "meta.20210123T153100.json" {
+			assert.NoError(t, json.NewDecoder(tr).Decode(&got))
+		}
+	}
+
+	assert.Equal(t, []string{
+		"meta.20210123T153100.json", "databases.20210123T153100.json",
+		"databases.20210123T153101.json",
+		"meta.20210123T153102.json", "databases.20210123T153102.json",
+	}, names)
+
+	assert.Equal(t, "pgcenter v0.9.0", got.Version)
+	assert.Equal(t, "13.0", got.Postgres.Version)
+	assert.Equal(t, opts, got.Options)
+	assert.NotEqual(t, "", got.Hostname)
+	assert.Equal(t, map[string][]string{"databases": {"datname", "xact_commit"}}, got.Views)
+}
@@ -4,22 +4,39 @@ package record
 
 import (
 	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
 	"github.com/lesovsky/pgcenter/internal/postgres"
 	"github.com/lesovsky/pgcenter/internal/query"
 	"github.com/lesovsky/pgcenter/internal/stat"
 	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
 // Config defines config container for configuring 'pgcenter record'.
 type Config struct {
-	Interval    time.Duration // Statistics recording interval
-	Count       int           // Number of statistics snapshot to record
-	OutputFile  string        // File where statistics will be saved
-	AppendFile  bool          // Append data to file
-	StringLimit int           // Limit of the length, to which query should be trimmed
+	Interval         time.Duration            // Statistics recording interval
+	Count            int                      // Number of statistics snapshot to record
+	OutputFile       string                   // File where statistics will be saved
+	AppendFile       bool                     // Append data to file
+	StringLimit      int                      // Limit of the length, to which query should be trimmed
+	ViewsFile        string                   // File with user-defined views
+	Daemon           bool                     // Keep recording on errors: reconnect to Postgres, log errors and mark gaps
+	LogFile          string                   // File where errors are logged in daemon mode, stderr is used by default
+	MaxSize          int64                    // Size of archive after which a new archive is started
+	RotateInterval   time.Duration            // Interval after which a new archive is started
+	Keep             int                      // Number of rotated archives to keep
+	Compress         bool                     // Write compressed and deduplicated stats
+	KeyframeInterval int                      // Number of snapshots between full snapshots in compressed archive
+	Views            []string                 // Names of views to record, all views are recorded by default
+	ViewIntervals    map[string]time.Duration // Recording intervals of views which differ from default interval
+	Version          string                   // Version of pgcenter, written into metadata of recording session
+	ASH              bool                     // Sample active sessions instead of recording stats
+	ASHInterval      time.Duration            // Interval of active sessions sampling
 }
 
 // RunMain is the 'pgcenter record' main entry point.
@@ -30,12 +47,28 @@ func RunMain(dbConfig postgres.Config, config Config) error {
 	if err != nil {
 		return err
 	}
+	defer app.db.Close()
 
-	fmt.Printf("INFO: recording to %s\n", config.OutputFile)
+	err = app.openLog()
+	if err != nil {
+		return err
+	}
+	defer app.closeLog()
 
-	// In case of SIGINT stop program gracefully
+	if config.MaxSize > 0 || config.RotateInterval > 0 {
+		ext := archiveExt(config.OutputFile)
+		fmt.Printf("INFO: recording to %s.*%s, archives are rotated\n", strings.TrimSuffix(config.OutputFile, ext), ext)
+	} else {
+		fmt.Printf("INFO: recording to %s\n", config.OutputFile)
+	}
+
+	// In case of SIGINT or SIGTERM stop program gracefully
 	doQuit := make(chan os.Signal, 1)
-	signal.Notify(doQuit, os.Interrupt)
+	signal.Notify(doQuit, os.Interrupt, syscall.SIGTERM)
+
+	// In case of SIGHUP reopen log file, used for log rotation
+	app.doReopen = make(chan os.Signal, 1)
+	signal.Notify(app.doReopen, syscall.SIGHUP)
 
 	// Run recording loop
 	return app.record(doQuit)
@@ -45,8 +78,14 @@ func RunMain(dbConfig postgres.Config, config Config) error {
 type app struct {
 	config   Config
 	dbConfig postgres.Config
+	db       *postgres.DB // connection used during the whole recording
 	views    view.Views
+	schedule schedule   // defines which stats are recorded at every tick of recording loop
+	ash      *ashBuffer // samples of active sessions, nil if sessions are not sampled
 	recorder recorder
+	log      io.Writer      // destination of logged errors in daemon mode
+	logFile  *os.File       // log file, nil if errors are logged to stderr
+	doReopen chan os.Signal // channel of signals which require reopening the log file
 }
 
 // newApp creates new 'pgcenter record' app.
@@ -54,53 +93,164 @@ func newApp(config Config, dbConfig postgres.Config) *app {
 	return &app{
 		config:   config,
 		dbConfig: dbConfig,
+		log:      os.Stderr,
 	}
 }
 
-// setup configures necessary queries depending on Postgres version.
+// setup connects to Postgres and configures necessary queries depending on Postgres version. The connection is
+// kept open and used for recording.
 func (app *app) setup() error {
 	db, err := postgres.Connect(app.dbConfig)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
 	props, err := stat.GetPostgresProperties(db)
 	if err != nil {
+		db.Close()
 		return err
 	}
 
 	// Create and configure stats views depending on running Postgres.
 	opts := query.NewOptions(props.VersionNum, props.Recovery, props.GucTrackCommitTimestamp, app.config.StringLimit)
 
+	var collector *stat.Collector
+	if app.config.ASH {
+		err = app.setupASH(opts)
+	} else {
+		collector, err = app.setupViews(db, props, opts)
+	}
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	meta := newMetadata(app.config.Version, props, opts)
+	meta.Interval = app.config.Interval
+	if app.config.ASH {
+		meta.ASHInterval = app.config.ASHInterval
+	}
+
+	tc := tarConfig{
+		filename: app.config.OutputFile,
+		append:   app.config.AppendFile,
+		system:   collector,
+		meta:     meta,
+		rotation: rotationConfig{
+			maxSize:  app.config.MaxSize,
+			interval: app.config.RotateInterval,
+			keep:     app.config.Keep,
+		},
+	}
+
+	// Create recorder.
+	if app.config.Compress {
+		app.recorder = newCompressedRecorder(tc, app.views, app.config.KeyframeInterval)
+	} else {
+		app.recorder = newTarRecorder(tc)
+	}
+
+	app.db = db
+
+	return nil
+}
+
+// setupViews configures views and system stats which should be recorded, and schedule of their recording. Returns
+// collector of system stats, or nil if system stats are not recorded.
+func (app *app) setupViews(db *postgres.DB, props stat.PostgresProperties, opts query.Options) (*stat.Collector, error) {
 	views := view.New()
+	err := views.LoadCustom(app.config.ViewsFile, archive.ReservedEntryNames)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record only requested views.
+	views, err = selectViews(views, app.config.Views)
+	if err != nil {
+		return nil, err
+	}
+
 	err = views.Configure(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	app.views = views
+
+	// System stats are available locally or remotely using pgcenter schema.
+	var collector *stat.Collector
+	system := selectSystem(app.config.Views)
+	err = checkSystemIntervals(system, app.config.ViewIntervals)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(system) > 0 {
+		if db.Local || props.SchemaPgcenterAvail {
+			collector, err = stat.NewCollector(db)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			fmt.Println("INFO: pgcenter schema is not found, system stats will not be recorded")
+			system = nil
+		}
+	}
+
+	app.schedule, err = newSchedule(recordedNames(views, system), app.config.Interval, app.config.ViewIntervals)
+	if err != nil {
+		return nil, err
+	}
+
+	return collector, nil
+}
+
+// setupASH configures sampling of active sessions. Sessions are sampled with sampling interval, accumulated samples
+// are written once per recording interval.
+func (app *app) setupASH(opts query.Options) error {
+	views := view.NewASH()
+	err := views.Configure(opts)
 	if err != nil {
 		return err
 	}
 
+	if _, ok := views[archive.ASHEntryName]; !ok {
+		return fmt.Errorf("sampling of active sessions requires Postgres 9.6 or newer")
+	}
+
 	app.views = views
 
-	// Create tar recorder.
-	app.recorder = newTarRecorder(tarConfig{
-		filename: app.config.OutputFile,
-		append:   app.config.AppendFile,
-	})
+	app.schedule, err = newSchedule([]string{archive.ASHEntryName}, app.config.ASHInterval, nil)
+	if err != nil {
+		return err
+	}
 
+	app.ash = newASHBuffer(app.config.ASHInterval, app.config.Interval)
 	return nil
 }
 
+// loopCount returns number of recording loop iterations requested by user, zero or negative value means recording
+// continues until interrupted. Active sessions are sampled every loop iteration, but the count limits the number of
+// written recording intervals.
+func (app *app) loopCount() int {
+	if app.ash != nil && app.config.Count > 0 {
+		return app.config.Count * app.ash.flushEvery
+	}
+	return app.config.Count
+}
+
 // record collects statistics and stores into file.
 func (app *app) record(doQuit chan os.Signal) error {
 	var (
-		count    = app.config.Count
-		interval = app.config.Interval
+		count    = app.loopCount()
+		interval = app.schedule.tick
 	)
 
 	t := time.NewTicker(interval)
 
 	// record the number of snapshots requested by user (or record continuously until SIGINT will be received)
 	var n int
+	var inGap bool // recording is failing since the last successful snapshot
 	for {
 		if count > 0 && n >= count {
 			break
@@ -108,34 +258,104 @@ func (app *app) record(doQuit chan os.Signal) error {
 			n++
 		}
 
-		err := app.recorder.open()
+		err := app.snapshot(n - 1)
 		if err != nil {
-			return err
-		}
+			if !app.config.Daemon {
+				return err
+			}
 
-		stats, err := app.recorder.collect(app.dbConfig, app.views)
-		if err != nil {
-			return err
+			app.logf("ERROR: recording stats failed: %s", err)
+
+			// Mark the beginning of the gap, report doesn't calculate deltas across gaps.
+			if !inGap {
+				err := app.store(time.Now(), newGapStats(err))
+				if err != nil {
+					app.logf("ERROR: writing gap marker failed: %s", err)
+				}
+				inGap = true
+			}
+
+			err = app.reconnect(doQuit)
+			if err != nil {
+				t.Stop()
+				return err
+			}
+		} else if inGap {
+			app.logf("INFO: recording resumed")
+			inGap = false
 		}
 
-		err = app.recorder.write(stats)
-		if err != nil {
-			return err
+	wait:
+		for {
+			select {
+			case <-t.C:
+				break wait
+			case <-app.doReopen:
+				err := app.reopenLog()
+				if err != nil {
+					t.Stop()
+					return err
+				}
+			case sig := <-doQuit:
+				t.Stop()
+				if err := app.flushASH(); err != nil {
+					app.logf("ERROR: writing samples of active sessions failed: %s", err)
+				}
+				return fmt.Errorf("got %s", sig.String())
+			}
 		}
+	}
 
-		err = app.recorder.close()
-		if err != nil {
-			return err
+	// Write remaining samples of active sessions.
+	return app.flushASH()
+}
+
+// snapshot collects stats which are due at the specified tick of recording loop and writes them into file.
+func (app *app) snapshot(tick int) error {
+	if app.ash != nil {
+		return app.sampleASH(tick)
+	}
+
+	// All stats of the snapshot are stamped with the same timestamp.
+	ts := time.Now()
+
+	due := app.schedule.due(tick)
+	views := view.Views{}
+	for name, v := range app.views {
+		if due[name] {
+			views[name] = v
 		}
+	}
 
-		select {
-		case <-t.C:
-			continue
-		case sig := <-doQuit:
-			t.Stop()
-			return fmt.Errorf("got %s", sig.String())
+	// System stats are collected only when they are due, hence their rates are calculated over the recording
+	// interval rather than over the last tick.
+	stats, err := app.recorder.collect(app.db, views, systemDue(due))
+	if err != nil {
+		return err
+	}
+
+	// System stats are collected together, store only selected ones.
+	for name := range stats {
+		if !due[name] {
+			delete(stats, name)
 		}
 	}
 
-	return nil
+	return app.store(ts, stats)
+}
+
+// store opens file, writes stats and closes file.
+func (app *app) store(ts time.Time, stats map[string]stat.PGresult) error {
+	err := app.recorder.open()
+	if err != nil {
+		return err
+	}
+
+	err = app.recorder.write(ts, stats)
+	if err != nil {
+		_ = app.recorder.close()
+		return err
+	}
+
+	return app.recorder.close()
 }
@@ -27,6 +27,9 @@ func Test_app_setup(t *testing.T) {
 		assert.NotEqual(t, "", v.Query) // view's queries must not be empty (must be created using templates)
 	}
 	assert.NotNil(t, app.recorder)
+	if assert.NotNil(t, app.db) {
+		app.db.Close()
+	}
 }
 
 func Test_app_record(t *testing.T) {
@@ -72,6 +75,7 @@ func Test_app_record(t *testing.T) {
 			assert.NoError(t, app.setup())
 
 			assert.NoError(t, app.record(doQuit))
+			app.db.Close()
 
 			// Read written stats.
 			f, err := os.Open(filepath.Clean(filename))
@@ -79,6 +83,7 @@ func Test_app_record(t *testing.T) {
 			tr := tar.NewReader(f)
 
 			var filesCount int
+			timestamps := map[string]struct{}{}
 			for {
 				hdr, err := tr.Next()
 				if err == io.EOF {
@@ -87,9 +92,13 @@ func Test_app_record(t *testing.T) {
 				filesCount++
 				assert.NoError(t, err)
 				assert.Greater(t, hdr.Size, int64(0))
+				timestamps[hdr.ModTime.String()] = struct{}{}
 			}
 			assert.Greater(t, filesCount, 0)
 			assert.Equal(t, tc.filesWant, filesCount)
+
+			// All stats of the snapshot are stamped with the same timestamp.
+			assert.Equal(t, tc.filesWant/totalViews, len(timestamps))
 		})
 	}
 	assert.NoError(t, os.Remove(filename))
@@ -2,8 +2,11 @@ package record
 
 import (
 	"archive/tar"
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/lesovsky/pgcenter/internal/archive"
 	"github.com/lesovsky/pgcenter/internal/postgres"
 	"github.com/lesovsky/pgcenter/internal/stat"
 	"github.com/lesovsky/pgcenter/internal/view"
@@ -16,8 +19,8 @@ import (
 // recorder defines a way of how to record and store collected stats.
 type recorder interface {
 	open() error
-	collect(dbConfig postgres.Config, views view.Views) (map[string]stat.PGresult, error)
-	write(map[string]stat.PGresult) error
+	collect(db *postgres.DB, views view.Views, system bool) (map[string]stat.PGresult, error)
+	write(ts time.Time, stats map[string]stat.PGresult) error
 	close() error
 }
 
@@ -25,15 +28,24 @@ type recorder interface {
 type tarConfig struct {
 	filename string
 	append   bool
+	system   *stat.Collector   // collector of system stats, nil if system stats are not recorded
+	rotation rotationConfig    // archives rotation settings, archives are not rotated by default
+	meta     *archive.Metadata // metadata of recording session, nil if metadata is not written
 }
 
 // tarRecorder implement recorder interface.
 // This implementation collects Postgres stats and stores it in .json files packed into .tar archive.
 type tarRecorder struct {
 	config    tarConfig
+	filename  string    // name of the current archive
+	started   time.Time // time when the current archive has been started, used for rotation
 	file      *os.File
 	fileFlags int
 	writer    *tar.Writer
+	index     *os.File             // index of the current archive, nil if index is not written
+	snapshot  int64                // offset of the currently written snapshot
+	entries   []archive.IndexEntry // index entries of the currently written snapshot
+	newMeta   bool                 // metadata should be written along with the next snapshot
 }
 
 // newTarRecorder creates new recorder.
@@ -45,15 +57,30 @@ func newTarRecorder(c tarConfig) recorder {
 		flags = os.O_CREATE | os.O_RDWR | os.O_TRUNC
 	}
 
+	// Name of rotated archive is chosen at opening.
+	var filename string
+	if !c.rotation.enabled() {
+		filename = c.filename
+	}
+
 	return &tarRecorder{
 		config:    c,
+		filename:  filename,
 		fileFlags: flags,
+		newMeta:   true,
 	}
 }
 
 // open method opens tar archive.
 func (c *tarRecorder) open() error {
-	f, err := os.OpenFile(filepath.Clean(c.config.filename), c.fileFlags, 0600)
+	if c.config.rotation.enabled() {
+		err := c.rotate(time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(filepath.Clean(c.filename), c.fileFlags, 0600)
 	if err != nil {
 		return err
 	}
@@ -62,6 +89,7 @@ func (c *tarRecorder) open() error {
 	// If truncate is not requested check the file size. For empty files set
 	// offset to 0 - start writing from beginning. For non-empty files set
 	// offset to -1024 - start writing from last kB, to avoid overwrite tar metadata.
+	fresh := true
 	if (c.fileFlags & os.O_TRUNC) == 0 {
 		var offset int64
 
@@ -72,16 +100,30 @@ func (c *tarRecorder) open() error {
 
 		if st.Size() > 0 {
 			offset = -1024
+			fresh = false
 		}
 
-		_, err = f.Seek(offset, io.SeekEnd)
+		c.snapshot, err = f.Seek(offset, io.SeekEnd)
 		if err != nil {
 			return err
 		}
 	} else {
 		// If truncate was requested, disable O_TRUNC ans use just O_RDWR to
-		// avoid further archive truncation.
-		c.fileFlags = os.O_RDWR
+		// avoid further archive truncation. Keep O_CREATE to start a new archive
+		// if the file has been moved away (e.g. rotated).
+		c.fileFlags = os.O_CREATE | os.O_RDWR
+		c.snapshot = 0
+	}
+
+	err = c.openIndex(fresh)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	// Every archive starts with metadata.
+	if fresh {
+		c.newMeta = true
 	}
 
 	c.file = f
@@ -90,9 +132,10 @@ func (c *tarRecorder) open() error {
 	return nil
 }
 
-// collect connects to Postgres, collects and returns stats data.
-func (c *tarRecorder) collect(dbConfig postgres.Config, views view.Views) (map[string]stat.PGresult, error) {
-	db, err := postgres.Connect(dbConfig)
+// collect collects and returns stats data. All views are queried within single read-only transaction, hence stats
+// of all views are taken from the same statistics snapshot. System stats are collected if requested.
+func (c *tarRecorder) collect(db *postgres.DB, views view.Views, system bool) (map[string]stat.PGresult, error) {
+	tx, err := db.Conn.BeginTx(context.Background(), pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
 	if err != nil {
 		return nil, err
 	}
@@ -102,40 +145,84 @@ func (c *tarRecorder) collect(dbConfig postgres.Config, views view.Views) (map[s
 	for k, v := range views {
 		res, err := stat.NewPGresult(db, v.Query)
 		if err != nil {
+			_ = tx.Rollback(context.Background())
 			return nil, err
 		}
 
 		stats[k] = res
 	}
 
+	// Collect system stats if required.
+	if system && c.config.system != nil {
+		sys, err := c.config.system.UpdateSystem(db)
+		if err != nil {
+			_ = tx.Rollback(context.Background())
+			return nil, err
+		}
+
+		for k, v := range stat.SystemResults(sys) {
+			stats[k] = v
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
-// write accepts stats data and writes it into tar archive.
-func (c *tarRecorder) write(stats map[string]stat.PGresult) error {
+// write accepts stats data and writes it into tar archive. All stats are stamped with the same timestamp.
+func (c *tarRecorder) write(ts time.Time, stats map[string]stat.PGresult) error {
+	err := c.writeMetadata(ts, stats, true)
+	if err != nil {
+		return err
+	}
+
 	for name, v := range stats {
 		data, err := json.Marshal(v)
 		if err != nil {
 			return err
 		}
 
-		now := time.Now()
-		filename := fmt.Sprintf("%s.%s.json", name, now.Format("20060102T150405"))
-		hdr := &tar.Header{Name: filename, Mode: 0644, Size: int64(len(data)), ModTime: now}
-		err = c.writer.WriteHeader(hdr)
+		filename := fmt.Sprintf("%s.%s.json", name, ts.Format("20060102T150405"))
+		err = c.writeEntry(filename, ts, data, true)
 		if err != nil {
 			return err
 		}
+	}
+	return nil
+}
+
+// writeEntry writes entry into archive and remembers its location for the index. Data of entries written directly
+// into the file could be read using their offsets, other entries are indexed only by snapshot's location.
+func (c *tarRecorder) writeEntry(filename string, ts time.Time, data []byte, direct bool) error {
+	hdr := &tar.Header{Name: filename, Mode: 0644, Size: int64(len(data)), ModTime: ts}
+	err := c.writer.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
 
-		_, err = c.writer.Write(data)
+	// After writing the header, file position points to the beginning of the entry's data.
+	offset := int64(-1)
+	if direct {
+		offset, err = c.file.Seek(0, io.SeekCurrent)
 		if err != nil {
 			return err
 		}
 	}
+
+	_, err = c.writer.Write(data)
+	if err != nil {
+		return err
+	}
+
+	c.addIndexEntry(filename, offset, hdr.Size)
 	return nil
 }
 
-// close closes recorder's file and tar writer descriptors.
+// close closes recorder's file and tar writer descriptors. Index is written when the snapshot is written completely.
 func (c *tarRecorder) close() error {
 	if c.writer != nil {
 		err := c.writer.Close()
@@ -144,5 +231,12 @@ func (c *tarRecorder) close() error {
 		}
 	}
 
-	return c.file.Close()
+	err := c.file.Close()
+	if err != nil {
+		c.entries = nil // snapshot might not be written, don't index it
+		_ = c.closeIndex()
+		return err
+	}
+
+	return c.closeIndex()
 }
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func Test_tarRecorder_open_close(t *testing.T) {
@@ -37,14 +38,11 @@ func Test_tarRecorder(t *testing.T) {
 	views := view.New()
 	opts := query.NewOptions(props.VersionNum, props.Recovery, props.GucTrackCommitTimestamp, 0)
 	assert.NoError(t, views.Configure(opts))
-	db.Close()
 
-	// create postgres config
-	dbConfig, err := postgres.NewTestConfig()
-	assert.NoError(t, err)
-	stats, err := tc.collect(dbConfig, views)
+	stats, err := tc.collect(db, views, true)
 	assert.NoError(t, err)
 	assert.NotNil(t, stats)
+	db.Close()
 
 	// check all stats have filled columns
 	for _, s := range stats {
@@ -72,7 +70,7 @@ func Test_tarRecorder_write(t *testing.T) {
 	// Write testdata.
 	tc := newTarRecorder(tarConfig{filename: filename, append: false})
 	assert.NoError(t, tc.open())
-	assert.NoError(t, tc.write(stats))
+	assert.NoError(t, tc.write(time.Now(), stats))
 	assert.NoError(t, tc.close())
 
 	// Read written testdata and compare with origin testdata.
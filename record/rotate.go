@@ -0,0 +1,162 @@
+package record
+
+import (
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rotatedTsFormat defines format of timestamp used in names of rotated archives.
+const rotatedTsFormat = "20060102T150405"
+
+// rotationConfig defines settings of archives rotation.
+type rotationConfig struct {
+	maxSize  int64         // size of archive after which a new archive is started, zero means no limit
+	interval time.Duration // interval after which a new archive is started, zero means no limit
+	keep     int           // number of archives to keep, zero means keep all archives
+}
+
+// enabled returns true if archives rotation is required.
+func (c rotationConfig) enabled() bool {
+	return c.maxSize > 0 || c.interval > 0
+}
+
+// rotate chooses archive where stats should be written. A new archive is started when the current archive exceeds
+// size or age limit, old archives are removed accordingly to the number of archives to keep.
+func (c *tarRecorder) rotate(now time.Time) error {
+	// When appending, continue the latest archive if it exists.
+	if c.filename == "" && c.config.append {
+		files, err := listRotated(c.config.filename)
+		if err != nil {
+			return err
+		}
+
+		if len(files) > 0 {
+			last := files[len(files)-1]
+			ts, _ := rotatedTimestamp(c.config.filename, last)
+			c.filename, c.started = last, ts
+		}
+	}
+
+	required := c.filename == ""
+
+	if !required && c.config.rotation.interval > 0 && now.Sub(c.started) >= c.config.rotation.interval {
+		required = true
+	}
+
+	if !required && c.config.rotation.maxSize > 0 {
+		st, err := os.Stat(c.filename)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err == nil && st.Size() >= c.config.rotation.maxSize {
+			required = true
+		}
+	}
+
+	if !required {
+		return nil
+	}
+
+	// Remove old archives, keep space for the new one.
+	if c.config.rotation.keep > 0 {
+		err := pruneRotated(c.config.filename, c.config.rotation.keep-1)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.filename = rotatedName(c.config.filename, now)
+	c.started = now
+	c.fileFlags = os.O_CREATE | os.O_RDWR | os.O_TRUNC
+
+	return nil
+}
+
+// rotatedName returns name of rotated archive based on base archive name and timestamp, e.g. for base name
+// 'pgcenter.stat.tar' it returns 'pgcenter.stat.20210123T153100.tar'.
+func rotatedName(base string, ts time.Time) string {
+	ext := archiveExt(base)
+	return strings.TrimSuffix(base, ext) + "." + ts.Format(rotatedTsFormat) + ext
+}
+
+// rotatedTimestamp parses timestamp from the name of rotated archive.
+func rotatedTimestamp(base string, name string) (time.Time, error) {
+	ext := archiveExt(base)
+	prefix := strings.TrimSuffix(base, ext) + "."
+
+	ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+	return time.ParseInLocation(rotatedTsFormat, ts, time.Now().Location())
+}
+
+// listRotated returns sorted (from oldest to newest) list of rotated archives with the specified base name.
+func listRotated(base string) ([]string, error) {
+	ext := archiveExt(base)
+	matches, err := filepath.Glob(globEscape(strings.TrimSuffix(base, ext)) + ".*" + globEscape(ext))
+	if err != nil {
+		return nil, err
+	}
+
+	// Glob might return unrelated files, take only archives with valid timestamp.
+	var files []string
+	for _, m := range matches {
+		if _, err := rotatedTimestamp(base, m); err == nil {
+			files = append(files, m)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// pruneRotated removes the oldest rotated archives and keeps only specified number of the newest archives.
+func pruneRotated(base string, keep int) error {
+	files, err := listRotated(base)
+	if err != nil {
+		return err
+	}
+
+	if len(files) <= keep {
+		return nil
+	}
+
+	for _, f := range files[:len(files)-keep] {
+		err := os.Remove(f)
+		if err != nil {
+			return err
+		}
+
+		// Remove index of the archive, archives recorded by previous versions have no index.
+		err = os.Remove(archive.IndexName(f))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveExt returns extension of the archive name, compressed archives have double extension.
+func archiveExt(name string) string {
+	if strings.HasSuffix(name, ".tar.gz") {
+		return ".tar.gz"
+	}
+	return filepath.Ext(name)
+}
+
+// globEscape escapes glob meta characters in the string.
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
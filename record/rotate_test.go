@@ -0,0 +1,76 @@
+package record
+
+import (
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_rotatedName(t *testing.T) {
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Local)
+
+	assert.Equal(t, "/tmp/pgcenter.stat.20210123T153100.tar", rotatedName("/tmp/pgcenter.stat.tar", ts))
+	assert.Equal(t, "/tmp/stats.20210123T153100", rotatedName("/tmp/stats", ts))
+	assert.Equal(t, "/tmp/pgcenter.stat.20210123T153100.tar.gz", rotatedName("/tmp/pgcenter.stat.tar.gz", ts))
+
+	got, err := rotatedTimestamp("/tmp/pgcenter.stat.tar", "/tmp/pgcenter.stat.20210123T153100.tar")
+	assert.NoError(t, err)
+	assert.Equal(t, ts, got)
+
+	_, err = rotatedTimestamp("/tmp/pgcenter.stat.tar", "/tmp/pgcenter.stat.tar")
+	assert.Error(t, err)
+}
+
+func Test_tarRecorder_rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-record-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	base := filepath.Join(dir, "pgcenter.stat.tar")
+
+	// Unrelated file must not be touched.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pgcenter.stat.example.tar"), []byte("example"), 0600))
+
+	c := newTarRecorder(tarConfig{filename: base, rotation: rotationConfig{maxSize: 1, interval: time.Hour, keep: 2}}).(*tarRecorder)
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Local)
+
+	// First archive.
+	assert.NoError(t, c.rotate(ts))
+	assert.Equal(t, rotatedName(base, ts), c.filename)
+	assert.NoError(t, ioutil.WriteFile(c.filename, nil, 0600))
+	assert.NoError(t, ioutil.WriteFile(archive.IndexName(c.filename), nil, 0600))
+
+	// Empty archive doesn't exceed size limit and age limit.
+	assert.NoError(t, c.rotate(ts.Add(time.Minute)))
+	assert.Equal(t, rotatedName(base, ts), c.filename)
+
+	// Age limit exceeded.
+	assert.NoError(t, c.rotate(ts.Add(time.Hour)))
+	assert.Equal(t, rotatedName(base, ts.Add(time.Hour)), c.filename)
+	assert.NoError(t, ioutil.WriteFile(c.filename, []byte("example"), 0600))
+
+	// Size limit exceeded, the oldest archive is removed.
+	assert.NoError(t, c.rotate(ts.Add(time.Hour+time.Minute)))
+	assert.Equal(t, rotatedName(base, ts.Add(time.Hour+time.Minute)), c.filename)
+	assert.NoError(t, ioutil.WriteFile(c.filename, []byte("example"), 0600))
+
+	files, err := listRotated(base)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{rotatedName(base, ts.Add(time.Hour)), rotatedName(base, ts.Add(time.Hour+time.Minute))}, files)
+
+	// Index of the removed archive is removed too.
+	_, err = os.Stat(archive.IndexName(rotatedName(base, ts)))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = os.Stat(filepath.Join(dir, "pgcenter.stat.example.tar"))
+	assert.NoError(t, err)
+
+	// Appending recorder continues the latest archive.
+	c = newTarRecorder(tarConfig{filename: base, append: true, rotation: rotationConfig{interval: time.Hour}}).(*tarRecorder)
+	assert.NoError(t, c.rotate(ts.Add(time.Hour+2*time.Minute)))
+	assert.Equal(t, rotatedName(base, ts.Add(time.Hour+time.Minute)), c.filename)
+}
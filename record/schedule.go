@@ -0,0 +1,160 @@
+package record
+
+import (
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"sort"
+	"time"
+)
+
+// schedule defines which stats are recorded at every tick of the recording loop. Stats are recorded with their own
+// intervals, which are rounded to the multiple of the shortest interval (tick).
+type schedule struct {
+	tick  time.Duration  // interval of the recording loop
+	every map[string]int // number of ticks between recording of stats
+}
+
+// newSchedule creates schedule of recording for specified stats. Stats are recorded with default interval unless
+// interval is overridden for them.
+func newSchedule(names []string, interval time.Duration, overrides map[string]time.Duration) (schedule, error) {
+	if len(names) == 0 {
+		return schedule{}, fmt.Errorf("no stats to record")
+	}
+
+	if interval <= 0 {
+		return schedule{}, fmt.Errorf("recording interval must be positive")
+	}
+
+	known := map[string]bool{}
+	for _, name := range names {
+		known[name] = true
+	}
+
+	for name, itv := range overrides {
+		if !known[name] {
+			return schedule{}, fmt.Errorf("interval specified for %s, but it is not recorded", name)
+		}
+
+		if itv <= 0 {
+			return schedule{}, fmt.Errorf("interval for %s must be positive", name)
+		}
+	}
+
+	// Recording loop ticks with the shortest interval.
+	intervals := map[string]time.Duration{}
+	var tick time.Duration
+	for _, name := range names {
+		itv, ok := overrides[name]
+		if !ok {
+			itv = interval
+		}
+		intervals[name] = itv
+
+		if tick == 0 || itv < tick {
+			tick = itv
+		}
+	}
+
+	s := schedule{tick: tick, every: map[string]int{}}
+	for name, itv := range intervals {
+		// Round interval to the nearest multiple of tick.
+		s.every[name] = int((itv + tick/2) / tick)
+	}
+
+	return s, nil
+}
+
+// due returns names of stats which should be recorded at the specified tick. All stats are recorded at the first
+// (zero) tick.
+func (s schedule) due(tick int) map[string]bool {
+	due := map[string]bool{}
+	for name, n := range s.every {
+		if tick%n == 0 {
+			due[name] = true
+		}
+	}
+
+	return due
+}
+
+// selectViews returns views which names are specified. All views are returned if no names are specified. Error is
+// returned if any of names is unknown; names of system stats are allowed too.
+func selectViews(views view.Views, names []string) (view.Views, error) {
+	if len(names) == 0 {
+		return views, nil
+	}
+
+	system := view.NewSystem()
+	selected := view.Views{}
+	for _, name := range names {
+		if v, ok := views[name]; ok {
+			selected[name] = v
+			continue
+		}
+
+		if _, ok := system[name]; !ok {
+			return nil, fmt.Errorf("unknown view: %s", name)
+		}
+	}
+
+	return selected, nil
+}
+
+// selectSystem returns names of system stats which are specified. All system stats are returned if no names are
+// specified.
+func selectSystem(names []string) []string {
+	var selected []string
+	for name := range view.NewSystem() {
+		if len(names) == 0 || contains(names, name) {
+			selected = append(selected, name)
+		}
+	}
+	sort.Strings(selected)
+
+	return selected
+}
+
+// checkSystemIntervals checks intervals are not overridden for system stats. System stats are collected together
+// and their rates are calculated between consecutive collections, hence they are recorded with recording interval.
+func checkSystemIntervals(system []string, overrides map[string]time.Duration) error {
+	for _, name := range system {
+		if _, ok := overrides[name]; ok {
+			return fmt.Errorf("interval can't be specified for %s, system stats are recorded with recording interval", name)
+		}
+	}
+
+	return nil
+}
+
+// systemDue returns true if any of system stats should be recorded.
+func systemDue(due map[string]bool) bool {
+	for name := range view.NewSystem() {
+		if due[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordedNames returns sorted names of recorded views and system stats.
+func recordedNames(views view.Views, system []string) []string {
+	names := make([]string, 0, len(views)+len(system))
+	for name := range views {
+		names = append(names, name)
+	}
+	names = append(names, system...)
+	sort.Strings(names)
+
+	return names
+}
+
+// contains returns true if list contains the value.
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
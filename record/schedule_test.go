@@ -0,0 +1,107 @@
+package record
+
+import (
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_newSchedule(t *testing.T) {
+	names := []string{"activity", "databases", "sizes"}
+
+	testcases := []struct {
+		valid     bool
+		overrides map[string]time.Duration
+		tick      time.Duration
+		every     map[string]int
+	}{
+		{valid: true, tick: 10 * time.Second, every: map[string]int{"activity": 1, "databases": 1, "sizes": 1}},
+		{
+			valid: true, overrides: map[string]time.Duration{"sizes": 5 * time.Minute, "activity": time.Second},
+			tick: time.Second, every: map[string]int{"activity": 1, "databases": 10, "sizes": 300},
+		},
+		{
+			// Intervals are rounded to multiple of the shortest interval.
+			valid: true, overrides: map[string]time.Duration{"activity": 20 * time.Second, "databases": 20 * time.Second, "sizes": 50 * time.Second},
+			tick: 20 * time.Second, every: map[string]int{"activity": 1, "databases": 1, "sizes": 3},
+		},
+		{valid: false, overrides: map[string]time.Duration{"unknown": time.Second}},
+		{valid: false, overrides: map[string]time.Duration{"sizes": 0}},
+	}
+
+	for _, tc := range testcases {
+		s, err := newSchedule(names, 10*time.Second, tc.overrides)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.tick, s.tick)
+			assert.Equal(t, tc.every, s.every)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+
+	_, err := newSchedule(nil, 10*time.Second, nil)
+	assert.Error(t, err)
+
+	_, err = newSchedule(names, 0, nil)
+	assert.Error(t, err)
+}
+
+func Test_schedule_due(t *testing.T) {
+	s, err := newSchedule([]string{"activity", "sizes"}, time.Second, map[string]time.Duration{"sizes": 3 * time.Second})
+	assert.NoError(t, err)
+
+	want := []map[string]bool{
+		{"activity": true, "sizes": true},
+		{"activity": true},
+		{"activity": true},
+		{"activity": true, "sizes": true},
+	}
+
+	for i, w := range want {
+		assert.Equal(t, w, s.due(i))
+	}
+}
+
+func Test_selectViews(t *testing.T) {
+	views := view.New()
+
+	got, err := selectViews(views, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, views, got)
+
+	got, err = selectViews(views, []string{"tables", "indexes", "sysstat"})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Contains(t, got, "tables")
+	assert.Contains(t, got, "indexes")
+
+	_, err = selectViews(views, []string{"tables", "unknown"})
+	assert.Error(t, err)
+}
+
+func Test_selectSystem(t *testing.T) {
+	assert.Equal(t, []string{"diskstats", "netdev", "sysstat"}, selectSystem(nil))
+	assert.Equal(t, []string{"sysstat"}, selectSystem([]string{"tables", "sysstat"}))
+	assert.Len(t, selectSystem([]string{"tables"}), 0)
+}
+
+func Test_checkSystemIntervals(t *testing.T) {
+	assert.NoError(t, checkSystemIntervals([]string{"sysstat"}, nil))
+	assert.NoError(t, checkSystemIntervals([]string{"sysstat"}, map[string]time.Duration{"sizes": time.Minute}))
+	assert.Error(t, checkSystemIntervals([]string{"sysstat"}, map[string]time.Duration{"sysstat": time.Minute}))
+}
+
+func Test_systemDue(t *testing.T) {
+	s, err := newSchedule([]string{"activity", "sysstat"}, 10*time.Second, map[string]time.Duration{"activity": time.Second})
+	assert.NoError(t, err)
+	assert.True(t, systemDue(s.due(0)))
+	assert.False(t, systemDue(s.due(1)))
+	assert.True(t, systemDue(s.due(10)))
+}
+
+func Test_recordedNames(t *testing.T) {
+	views := view.Views{"tables": {}, "activity": {}}
+	assert.Equal(t, []string{"activity", "sysstat", "tables"}, recordedNames(views, []string{"sysstat"}))
+}
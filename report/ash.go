@@ -0,0 +1,315 @@
+package report
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ashReportType defines type of the report which breaks down database time using samples of active sessions.
+	ashReportType = "ash"
+)
+
+// ashDimensions defines columns of samples by which database time is broken down, and their titles.
+var ashDimensions = []struct {
+	col   string
+	title string
+}{
+	{col: "wait_event", title: "wait event"},
+	{col: "query_id", title: "query"},
+	{col: "usename", title: "user"},
+	{col: "datname", title: "database"},
+}
+
+// ashCols defines names of columns with aggregated values printed in ASH report.
+var ashCols = []string{"samples", "db_time", "pct", "aas"}
+
+// ashReport aggregates samples of active sessions over the report interval. Every sample of the session is
+// accounted as sampling interval of database time spent by the session.
+type ashReport struct {
+	interval time.Duration             // interval of sessions sampling
+	ticks    map[string]bool           // sampling ticks, samples of the single tick have the same sample time
+	samples  int                       // number of samples of active sessions
+	idle     int                       // number of samples of sessions idle in transaction or waiting for client
+	counts   map[string]map[string]int // number of samples by dimensions and their values
+	queries  map[string]string         // texts of sampled queries by their ids
+}
+
+// newASHReport creates report of sessions sampled with specified interval.
+func newASHReport(interval time.Duration) *ashReport {
+	r := &ashReport{
+		interval: interval,
+		ticks:    map[string]bool{},
+		counts:   map[string]map[string]int{},
+		queries:  map[string]string{},
+	}
+
+	for _, d := range ashDimensions {
+		r.counts[d.col] = map[string]int{}
+	}
+
+	return r
+}
+
+// add aggregates samples taken within the interval. Samples could be filtered by values of sampled columns, but
+// sampling ticks are counted regardless of filter.
+func (r *ashReport) add(res stat.PGresult, start, end time.Time, c Config) error {
+	idx := map[string]int{}
+	for _, name := range []string{"sample_time", "pid", "usename", "datname", "state", "wait_event_type", "wait_event", "query_id"} {
+		n, ok := getColumnIndex(res.Cols, name)
+		if !ok {
+			return fmt.Errorf("column %s not found in samples of active sessions", name)
+		}
+		idx[name] = n
+	}
+
+	var filterIdx = -1
+	if c.FilterColName != "" {
+		if n, ok := getColumnIndex(res.Cols, c.FilterColName); ok {
+			filterIdx = n
+		}
+	}
+
+	for _, row := range res.Values {
+		ms, err := strconv.ParseInt(row[idx["sample_time"]].String, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid sample time: %s", err)
+		}
+
+		ts := time.Unix(0, ms*int64(time.Millisecond))
+		if ts.Before(start) || ts.After(end) {
+			continue
+		}
+
+		r.ticks[row[idx["sample_time"]].String] = true
+
+		// Ticks without active sessions are recorded with empty samples.
+		if !row[idx["pid"]].Valid {
+			continue
+		}
+
+		if c.FilterColName != "" && (filterIdx < 0 || !c.FilterRE.MatchString(row[filterIdx].String)) {
+			continue
+		}
+
+		// Sessions idle in transaction or waiting for client are not accounted as database time.
+		if !isDatabaseTime(row[idx["state"]].String, row[idx["wait_event_type"]].String) {
+			r.idle++
+			continue
+		}
+
+		r.samples++
+		r.counts["wait_event"][waitEventLabel(row[idx["state"]].String, row[idx["wait_event_type"]].String, row[idx["wait_event"]].String)]++
+		r.counts["query_id"][row[idx["query_id"]].String]++
+		r.counts["usename"][row[idx["usename"]].String]++
+		r.counts["datname"][row[idx["datname"]].String]++
+	}
+
+	return nil
+}
+
+// addQueries remembers texts of sampled queries.
+func (r *ashReport) addQueries(res stat.PGresult) {
+	for _, row := range res.Values {
+		if len(row) > 1 {
+			r.queries[row[0].String] = row[1].String
+		}
+	}
+}
+
+// isDatabaseTime returns true if the sampled session spends database time. Sessions idle in transaction and sessions
+// waiting for client don't work in database, though they are not idle.
+func isDatabaseTime(state, eventType string) bool {
+	return !strings.HasPrefix(state, "idle") && eventType != "Client"
+}
+
+// waitEventLabel returns name of the event the session has been waiting on. Active sessions which are not waiting
+// are accounted as working on CPU, other sessions are accounted by their state.
+func waitEventLabel(state, eventType, event string) string {
+	switch {
+	case eventType != "" && event != "":
+		return eventType + ":" + event
+	case state == "active":
+		return "CPU"
+	default:
+		return state
+	}
+}
+
+// result returns database time broken down by the dimension, ranked by number of samples and limited by the row
+// limit. Breakdown by queries also contains texts of queries.
+func (r *ashReport) result(dimension string, limit int) stat.PGresult {
+	counts := r.counts[dimension]
+
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	res := stat.PGresult{Cols: append([]string{dimension}, ashCols...)}
+	if dimension == "query_id" {
+		res.Cols = append(res.Cols, "query")
+	}
+
+	for _, k := range keys {
+		n := counts[k]
+
+		var pct, aas float64
+		if r.samples > 0 {
+			pct = float64(n) / float64(r.samples) * 100
+		}
+		if len(r.ticks) > 0 {
+			aas = float64(n) / float64(len(r.ticks))
+		}
+
+		values := []sql.NullString{
+			{String: k, Valid: true},
+			{String: strconv.Itoa(n), Valid: true},
+			{String: formatSummaryValue((time.Duration(n) * r.interval).Seconds()), Valid: true},
+			{String: strconv.FormatFloat(pct, 'f', 2, 64), Valid: true},
+			{String: strconv.FormatFloat(aas, 'f', 2, 64), Valid: true},
+		}
+
+		if dimension == "query_id" {
+			values = append(values, sql.NullString{String: r.queries[k], Valid: true})
+		}
+
+		res.Values = append(res.Values, values)
+	}
+
+	res.Ncols = len(res.Cols)
+	res.Nrows = len(res.Values)
+	res.Valid = true
+
+	return res
+}
+
+// combined returns breakdowns by all dimensions as single result, used for machine-readable formats.
+func (r *ashReport) combined(limit int) stat.PGresult {
+	res := stat.PGresult{Cols: append(append([]string{"dimension", "name"}, ashCols...), "query")}
+
+	for _, d := range ashDimensions {
+		part := r.result(d.col, limit)
+		for _, row := range part.Values {
+			values := append([]sql.NullString{{String: d.col, Valid: true}}, row[:len(ashCols)+1]...)
+			if d.col == "query_id" {
+				values = append(values, row[len(ashCols)+1])
+			} else {
+				values = append(values, sql.NullString{})
+			}
+			res.Values = append(res.Values, values)
+		}
+	}
+
+	res.Ncols = len(res.Cols)
+	res.Nrows = len(res.Values)
+	res.Valid = true
+
+	return res
+}
+
+// doASH reads samples of active sessions and prints database time broken down by wait events, queries, users and
+// databases. Samples are written after they have been taken, hence entries are read until the end of the interval
+// plus the recording interval.
+func (app *app) doASH(r entryReader, interval, sampleInterval time.Duration) error {
+	c := app.config
+	ash := newASHReport(sampleInterval)
+
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("advance read position failed: %s", err)
+		}
+
+		name := ashReportType
+		if isFilenameOK(hdr.Name, name) != nil {
+			name = archive.ASHQueriesEntryName
+			if isFilenameOK(hdr.Name, name) != nil {
+				continue
+			}
+		}
+
+		// Samples of active sessions are always written as-is.
+		if archive.IsDeltaEntry(hdr.Name) {
+			continue
+		}
+
+		_, err = isFilenameTimestampOK(hdr.Name, c.TsStart, c.TsEnd.Add(interval))
+		if err != nil {
+			continue
+		}
+
+		res, err := archive.ReadResult(r, hdr.Size)
+		if err != nil {
+			return fmt.Errorf("read %s failed: %s", hdr.Name, err)
+		}
+
+		if name == archive.ASHQueriesEntryName {
+			ash.addQueries(res)
+			continue
+		}
+
+		err = ash.add(res, c.TsStart, c.TsEnd, c)
+		if err != nil {
+			return fmt.Errorf("read %s failed: %s", hdr.Name, err)
+		}
+	}
+
+	return app.printASH(ash)
+}
+
+// printASH prints database time broken down by all dimensions in requested format.
+func (app *app) printASH(ash *ashReport) error {
+	ts := app.config.TsEnd
+
+	if app.formatter != nil {
+		return app.printAggregated(ash.combined(app.config.RowLimit), ts)
+	}
+
+	_, err := fmt.Fprintf(app.writer, "INFO: %d samples of active sessions over %d sampling ticks, sampling interval %s\n",
+		ash.samples, len(ash.ticks), ash.interval)
+	if err != nil {
+		return err
+	}
+
+	if ash.idle > 0 {
+		_, err := fmt.Fprintf(app.writer, "INFO: %d samples of sessions idle in transaction or waiting for client are not accounted as database time\n", ash.idle)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range ashDimensions {
+		_, err := fmt.Fprintf(app.writer, "INFO: database time by %s\n", d.title)
+		if err != nil {
+			return err
+		}
+
+		err = app.printAggregated(ash.result(d.col, app.config.RowLimit), ts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newASHTestSamples creates samples of active sessions; every row contains pid, usename, datname, state,
+// wait_event_type, wait_event and query_id. Rows without pid are ticks without active sessions.
+func newASHTestSamples(ts time.Time, rows ...[]string) stat.PGresult {
+	res := stat.PGresult{
+		Valid: true, Ncols: 8, Nrows: len(rows),
+		Cols: []string{"sample_time", "pid", "usename", "datname", "state", "wait_event_type", "wait_event", "query_id"},
+	}
+
+	for _, r := range rows {
+		values := []sql.NullString{{String: strconv.FormatInt(ts.UnixNano()/int64(time.Millisecond), 10), Valid: true}}
+		for _, v := range r {
+			values = append(values, sql.NullString{String: v, Valid: v != ""})
+		}
+		for len(values) < res.Ncols {
+			values = append(values, sql.NullString{})
+		}
+		res.Values = append(res.Values, values)
+	}
+
+	return res
+}
+
+func Test_waitEventLabel(t *testing.T) {
+	assert.Equal(t, "Lock:transactionid", waitEventLabel("active", "Lock", "transactionid"))
+	assert.Equal(t, "CPU", waitEventLabel("active", "", ""))
+	assert.Equal(t, "idle in transaction", waitEventLabel("idle in transaction", "", ""))
+}
+
+func Test_isDatabaseTime(t *testing.T) {
+	testcases := []struct {
+		state     string
+		eventType string
+		want      bool
+	}{
+		{state: "active", want: true},
+		{state: "active", eventType: "Lock", want: true},
+		{state: "fastpath function call", want: true},
+		{state: "active", eventType: "Client", want: false},
+		{state: "idle in transaction", eventType: "Client", want: false},
+		{state: "idle in transaction (aborted)", want: false},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, isDatabaseTime(tc.state, tc.eventType), tc.state+" "+tc.eventType)
+	}
+}
+
+func Test_ashReport(t *testing.T) {
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+	start, end := ts, ts.Add(time.Second)
+
+	r := newASHReport(100 * time.Millisecond)
+	assert.NoError(t, r.add(newASHTestSamples(ts,
+		[]string{"1", "alice", "shop", "active", "Lock", "transactionid", "q1"},
+		[]string{"2", "bob", "shop", "active", "", "", "q2"},
+	), start, end, Config{}))
+	assert.NoError(t, r.add(newASHTestSamples(ts.Add(100*time.Millisecond),
+		[]string{"1", "alice", "shop", "active", "Lock", "transactionid", "q1"},
+	), start, end, Config{}))
+	assert.NoError(t, r.add(newASHTestSamples(ts.Add(200*time.Millisecond), []string{}), start, end, Config{}))
+
+	// Sessions idle in transaction are not accounted as database time.
+	assert.NoError(t, r.add(newASHTestSamples(ts.Add(300*time.Millisecond),
+		[]string{"3", "carol", "shop", "idle in transaction", "Client", "ClientRead", "q3"},
+	), start, end, Config{}))
+
+	// Samples out of the interval are not aggregated.
+	assert.NoError(t, r.add(newASHTestSamples(ts.Add(2*time.Second),
+		[]string{"1", "alice", "shop", "active", "Lock", "transactionid", "q1"},
+	), start, end, Config{}))
+
+	r.addQueries(stat.PGresult{Values: [][]sql.NullString{
+		{{String: "q1", Valid: true}, {String: "update t set v = 1", Valid: true}},
+	}})
+
+	assert.Equal(t, 3, r.samples)
+	assert.Equal(t, 1, r.idle)
+	assert.Len(t, r.ticks, 4)
+
+	res := r.result("wait_event", 0)
+	assert.Equal(t, []string{"wait_event", "samples", "db_time", "pct", "aas"}, res.Cols)
+	want := [][]string{
+		{"Lock:transactionid", "2", "0.20", "66.67", "0.50"},
+		{"CPU", "1", "0.10", "33.33", "0.25"},
+	}
+	assert.Equal(t, len(want), res.Nrows)
+	for i, row := range want {
+		for j, value := range row {
+			assert.Equal(t, value, res.Values[i][j].String)
+		}
+	}
+
+	res = r.result("query_id", 1)
+	assert.Equal(t, 1, res.Nrows)
+	assert.Equal(t, "query", res.Cols[5])
+	assert.Equal(t, "update t set v = 1", res.Values[0][5].String)
+
+	res = r.combined(0)
+	assert.Equal(t, []string{"dimension", "name", "samples", "db_time", "pct", "aas", "query"}, res.Cols)
+	assert.Equal(t, 7, res.Nrows) // 2 wait events, 2 queries, 2 users, 1 database
+
+	// Filter.
+	r = newASHReport(100 * time.Millisecond)
+	assert.NoError(t, r.add(newASHTestSamples(ts,
+		[]string{"1", "alice", "shop", "active", "Lock", "transactionid", "q1"},
+		[]string{"2", "bob", "shop", "active", "", "", "q2"},
+	), start, end, Config{FilterColName: "usename", FilterRE: regexp.MustCompile("^bob$")}))
+	assert.Equal(t, 1, r.samples)
+	assert.Equal(t, 1, r.counts["wait_event"]["CPU"])
+
+	// Invalid samples.
+	assert.Error(t, r.add(stat.PGresult{Valid: true, Cols: []string{"pid"}}, start, end, Config{}))
+}
+
+func Test_app_doASH(t *testing.T) {
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range []struct {
+		name string
+		res  stat.PGresult
+	}{
+		{name: "ash.20210123T153101.json", res: newASHTestSamples(ts, []string{"1", "alice", "shop", "active", "IO", "DataFileRead", "q1"})},
+		{name: "ash_queries.20210123T153101.json", res: stat.PGresult{Valid: true, Ncols: 2, Nrows: 1, Cols: []string{"query_id", "query"},
+			Values: [][]sql.NullString{{{String: "q1", Valid: true}, {String: "select 1", Valid: true}}}}},
+		{name: "databases.20210123T153101.json", res: stat.PGresult{Valid: true}},
+	} {
+		data, err := json.Marshal(e.res)
+		assert.NoError(t, err)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0644, Size: int64(len(data)), ModTime: ts}))
+		_, err = tw.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+
+	var out bytes.Buffer
+	app := &app{config: Config{ReportType: "ash", TsStart: ts, TsEnd: ts, TruncLimit: 32}, writer: &out}
+	assert.NoError(t, app.doASH(tar.NewReader(&buf), time.Second, 100*time.Millisecond))
+
+	assert.Contains(t, out.String(), "INFO: 1 samples of active sessions over 1 sampling ticks")
+	assert.Contains(t, out.String(), "INFO: database time by wait event")
+	assert.Contains(t, out.String(), "IO:DataFileRead")
+	assert.Contains(t, out.String(), "select 1")
+}
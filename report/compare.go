@@ -0,0 +1,174 @@
+package report
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// compareCols defines names of columns with compared values printed in compare report.
+var compareCols = []string{"column", "before", "after", "diff", "diff_%"}
+
+// doCompare aggregates stats read from compared file ('before') and from report file ('after') and prints average
+// rates of both side-by-side.
+func (app *app) doCompare(after, before entryReader) error {
+	c := app.config
+
+	aggregate := func(r entryReader, start, end time.Time) (*summary, time.Time, error) {
+		v := app.view
+		s, err := newSummary(v)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		var last time.Time
+		err = app.readSamples(r, start, end, &v, func(ts time.Time, _, curr, prev stat.PGresult, itv float64) error {
+			last = ts
+			return s.add(curr, prev, itv, c)
+		}, nil)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		// Order might be configured by name of the column, remember it.
+		s.view = v
+		return s, last, nil
+	}
+
+	sb, _, err := aggregate(before, c.CompareStart, c.CompareEnd)
+	if err != nil {
+		return err
+	}
+
+	sa, ts, err := aggregate(after, c.TsStart, c.TsEnd)
+	if err != nil {
+		return err
+	}
+
+	if app.formatter == nil {
+		_, err := fmt.Fprintf(app.writer, "INFO: compare %d samples (before) with %d samples (after)\n", sb.samples, sa.samples)
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.printAggregated(compareSummaries(sb, sa, sa.view.OrderKey, sa.view.OrderDesc, c.RowLimit), ts)
+}
+
+// compareSummaries matches rows of two summaries by unique key and returns average rates of every aggregated column
+// of the rows, with absolute and percent change. Rows are ranked by absolute change of the order column and limited
+// by the row limit.
+func compareSummaries(before, after *summary, orderKey int, desc bool, limit int) stat.PGresult {
+	// Rows could be missing in any of summaries, use rows from both.
+	keys := make([]string, len(after.keys))
+	copy(keys, after.keys)
+	for _, key := range before.keys {
+		if _, ok := after.rows[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	cols := after.cols
+	if cols == nil {
+		cols = before.cols
+	}
+
+	// row returns the latest values of the row.
+	row := func(key string) *summaryRow {
+		if r, ok := after.rows[key]; ok {
+			return r
+		}
+		return before.rows[key]
+	}
+
+	// change returns absolute change of the column's rate.
+	change := func(key string, col int) float64 {
+		b, _ := before.rate(key, col)
+		a, _ := after.rate(key, col)
+		return a - b
+	}
+
+	// Rank rows by absolute change of the order column, or by its value if the column is not aggregated.
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := row(keys[i]), row(keys[j])
+		if orderKey < 0 || orderKey >= len(a.values) || orderKey >= len(b.values) {
+			return false
+		}
+
+		if a.series[orderKey] != nil {
+			ca, cb := math.Abs(change(keys[i], orderKey)), math.Abs(change(keys[j], orderKey))
+			if desc {
+				return ca > cb
+			}
+			return ca < cb
+		}
+
+		if desc {
+			return a.values[orderKey].String > b.values[orderKey].String
+		}
+		return a.values[orderKey].String < b.values[orderKey].String
+	})
+
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	// Columns of the result: not aggregated columns followed by compared values.
+	intvl := after.view.DiffIntvl
+	var res stat.PGresult
+	var plain []int
+	for i, name := range cols {
+		if i < intvl[0] || i > intvl[1] {
+			plain = append(plain, i)
+			res.Cols = append(res.Cols, name)
+		}
+	}
+	res.Cols = append(res.Cols, compareCols...)
+
+	for _, key := range keys {
+		r := row(key)
+		for i, ser := range r.series {
+			if ser == nil || i >= len(cols) {
+				continue
+			}
+
+			var values []sql.NullString
+			for _, idx := range plain {
+				values = append(values, r.values[idx])
+			}
+
+			b, bok := before.rate(key, i)
+			a, aok := after.rate(key, i)
+
+			bs, as, ds, ps := "-", "-", "-", "-"
+			if bok {
+				bs = formatSummaryValue(b)
+			}
+			if aok {
+				as = formatSummaryValue(a)
+			}
+			if bok && aok {
+				ds = formatSummaryValue(a - b)
+				if b != 0 {
+					ps = strconv.FormatFloat((a-b)/b*100, 'f', 2, 64)
+				}
+			}
+
+			for _, v := range []string{cols[i], bs, as, ds, ps} {
+				values = append(values, sql.NullString{String: v, Valid: true})
+			}
+
+			res.Values = append(res.Values, values)
+		}
+	}
+
+	res.Ncols = len(res.Cols)
+	res.Nrows = len(res.Values)
+	res.Valid = true
+
+	return res
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_compareSummaries(t *testing.T) {
+	v := view.View{Name: "tables", DiffIntvl: [2]int{1, 1}, UniqueKey: 0, OrderKey: 1, OrderDesc: true}
+	prev := newSummaryTestResult([2]string{"t1", "0"}, [2]string{"t2", "0"}, [2]string{"t3", "0"})
+
+	before, err := newSummary(v)
+	assert.NoError(t, err)
+	assert.NoError(t, before.add(newSummaryTestResult([2]string{"t1", "10"}, [2]string{"t2", "4"}), prev, 1, Config{}))
+	before.add(newSummaryTestResult([2]string{"t1", "10"}, [2]string{"t2", "0"}), prev, 1, Config{})
+
+	after, err := newSummary(v)
+	assert.NoError(t, err)
+	assert.NoError(t, after.add(newSummaryTestResult([2]string{"t1", "15"}, [2]string{"t3", "1"}), prev, 2, Config{}))
+
+	res := compareSummaries(before, after, v.OrderKey, v.OrderDesc, 0)
+	assert.Equal(t, []string{"relname", "column", "before", "after", "diff", "diff_%"}, res.Cols)
+
+	want := [][]string{
+		{"t1", "seq_scan", "10", "7.50", "-2.50", "-25.00"},
+		{"t2", "seq_scan", "2", "-", "-", "-"},
+		{"t3", "seq_scan", "-", "0.50", "-", "-"},
+	}
+	assert.Equal(t, len(want), res.Nrows)
+	for i, row := range want {
+		for j, value := range row {
+			assert.Equal(t, value, res.Values[i][j].String)
+		}
+	}
+
+	// Limit.
+	res = compareSummaries(before, after, v.OrderKey, v.OrderDesc, 1)
+	assert.Equal(t, 1, res.Nrows)
+	assert.Equal(t, "t1", res.Values[0][0].String)
+}
+
+func Test_app_doCompare(t *testing.T) {
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+
+	// newArchive returns archive with 'tables' samples of the single table recorded once a minute.
+	newArchive := func(values ...int) *tar.Reader {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for i, value := range values {
+			data, err := json.Marshal(newSummaryTestResult([2]string{"t1", strconv.Itoa(value)}))
+			assert.NoError(t, err)
+			sts := ts.Add(time.Duration(i) * time.Minute)
+			assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "tables." + sts.Format("20060102T150405") + ".json", Mode: 0644, Size: int64(len(data)), ModTime: sts}))
+			_, err = tw.Write(data)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, tw.Close())
+		return tar.NewReader(&buf)
+	}
+
+	var buf bytes.Buffer
+	formatter, err := newFormatter("csv", &buf)
+	assert.NoError(t, err)
+
+	app := &app{
+		config: Config{
+			ReportType: "tables", Rate: time.Second, TsStart: ts, TsEnd: ts.Add(time.Hour),
+			CompareStart: ts, CompareEnd: ts.Add(time.Hour),
+		},
+		view:      view.View{Name: "tables", DiffIntvl: [2]int{1, 1}, UniqueKey: 0, OrderKey: 1, OrderDesc: true, ColsWidth: map[int]int{}},
+		writer:    &buf,
+		formatter: formatter,
+	}
+
+	// Counters change slower than once per rate interval, rates must not be truncated to zero.
+	assert.NoError(t, app.doCompare(newArchive(0, 120), newArchive(0, 59)))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[1], ",t1,seq_scan,0.98,2,1.02,103.39"), lines[1])
+}
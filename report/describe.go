@@ -345,5 +345,97 @@ Details: https://www.postgresql.org/docs/current/pgstatstatements.html
 * - extended value, based on origin and calculated using additional functions.
 
 Details: https://www.postgresql.org/docs/current/pgstatstatements.html
+`
+
+	// sysstatDescription is the detailed description of system stats.
+	sysstatDescription = `System statistics based on /proc/loadavg, /proc/stat and /proc/meminfo:
+
+  column	origin		description
+- load1		loadavg		Load average over the last 1 minute
+- load5		loadavg		Load average over the last 5 minutes
+- load15	loadavg		Load average over the last 15 minutes
+- us		stat		Percent of time spent in user mode
+- sy		stat		Percent of time spent in system mode
+- ni		stat		Percent of time spent in user mode with low priority (nice)
+- id		stat		Percent of time spent in the idle task
+- wa		stat		Percent of time spent waiting for I/O to complete
+- hi		stat		Percent of time spent servicing hardware interrupts
+- si		stat		Percent of time spent servicing software interrupts
+- st		stat		Percent of time stolen by other operating systems running in a virtualized environment
+- mem_total	meminfo		Total usable memory, in MiB
+- mem_free	meminfo		Amount of free memory, in MiB
+- mem_used	meminfo		Amount of used memory, in MiB
+- buff_cached	meminfo		Amount of memory used by buffers, page cache and slab, in MiB
+- swap_total	meminfo		Total amount of swap space, in MiB
+- swap_free	meminfo		Amount of free swap space, in MiB
+- swap_used	meminfo		Amount of used swap space, in MiB
+- dirty		meminfo		Amount of memory waiting to be written back to the disk, in MiB
+- writeback	meminfo		Amount of memory actively being written back to the disk, in MiB
+
+Stats are recorded when Postgres is running locally or pgcenter schema is installed.
+`
+
+	// diskstatsDescription is the detailed description of block devices stats.
+	diskstatsDescription = `Block devices statistics based on /proc/diskstats:
+
+  column	description
+- device	Name of the block device
+- rrqm/s	Number of read requests merged per second
+- wrqm/s	Number of write requests merged per second
+- r/s		Number of read requests completed per second
+- w/s		Number of write requests completed per second
+- rMB/s		Number of megabytes read per second
+- wMB/s		Number of megabytes written per second
+- avgrq-sz	Average size of requests, in sectors
+- avgqu-sz	Average queue length of requests
+- await		Average time for requests to be served, in milliseconds
+- r_await	Average time for read requests to be served, in milliseconds
+- w_await	Average time for write requests to be served, in milliseconds
+- %util		Percentage of elapsed time during which requests were issued to the device
+
+Stats are recorded when Postgres is running locally or pgcenter schema is installed.
+`
+
+	// netdevDescription is the detailed description of network interfaces stats.
+	netdevDescription = `Network interfaces statistics based on /proc/net/dev:
+
+  column	description
+- interface	Name of the network interface
+- rMbps		Megabits received per second
+- wMbps		Megabits transmitted per second
+- rPk/s		Packets received per second
+- wPk/s		Packets transmitted per second
+- rAvs		Average size of packets received, in bytes
+- wAvs		Average size of packets transmitted, in bytes
+- IErr		Input errors per second
+- OErr		Output errors per second
+- Coll		Collisions per second
+- Sat		Saturation, number of errors per second seen for the interface
+- %rUtil	Percentage utilization for bytes received
+- %wUtil	Percentage utilization for bytes transmitted
+- %Util		Percentage utilization of the interface
+
+Stats are recorded when Postgres is running locally or pgcenter schema is installed.
+`
+
+	// ashDescription is the detailed description of active session history report.
+	ashDescription = `Active session history based on samples of pg_stat_activity view:
+
+Database time is broken down by wait events, queries, users and databases. Every sample of the
+active session is accounted as sampling interval of database time spent by the session.
+
+  column	description
+- wait_event	Event the session has been waiting on (wait_event_type:wait_event), CPU for active sessions
+		which were not waiting, or state of the session (e.g. idle in transaction)
+- query_id	Identifier of the query (queryid since Postgres 14, hash of the query text otherwise)
+- usename	Name of the user logged into the session
+- datname	Name of the database the session is connected to
+- samples	Number of samples of active sessions
+- db_time	Database time estimated from samples, in seconds
+- pct		Percentage of the total database time
+- aas		Average number of active sessions (samples per sampling tick)
+- query		Text of the query, with normalized whitespace
+
+Samples are recorded by 'pgcenter record --ash', Postgres 9.6 or newer is required.
 `
 )
@@ -0,0 +1,257 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// Supported output formats of report.
+	formatText     = "text"
+	formatCSV      = "csv"
+	formatJSON     = "json"
+	formatJSONL    = "jsonl"
+	formatMarkdown = "markdown"
+)
+
+// formatter defines writer of stats samples in machine-readable formats. Values are written as-is, without
+// aligning and truncating.
+type formatter interface {
+	// writeSample writes specified rows of the stats sample taken at ts.
+	writeSample(ts time.Time, res *stat.PGresult, rows []int) error
+	// flush finishes output.
+	flush() error
+}
+
+// newFormatter creates formatter for requested output format. Nil formatter is returned for text format, which is
+// printed aligned.
+func newFormatter(format string, w io.Writer) (formatter, error) {
+	switch format {
+	case "", formatText:
+		return nil, nil
+	case formatCSV:
+		return &csvFormatter{w: csv.NewWriter(w)}, nil
+	case formatJSON:
+		return &jsonFormatter{w: w, array: true}, nil
+	case formatJSONL:
+		return &jsonFormatter{w: w}, nil
+	case formatMarkdown:
+		return &markdownFormatter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+// selectRows returns indexes of the rows which satisfy filter, number of returned rows is limited by row limit.
+func selectRows(res *stat.PGresult, c Config) []int {
+	var rows []int
+
+	filterIdx := -1
+	if c.FilterColName != "" {
+		if idx, ok := getColumnIndex(res.Cols, c.FilterColName); ok {
+			filterIdx = idx
+		}
+	}
+
+	for i := 0; i < res.Nrows; i++ {
+		if c.FilterColName != "" && (filterIdx < 0 || !c.FilterRE.MatchString(res.Values[i][filterIdx].String)) {
+			continue
+		}
+
+		rows = append(rows, i)
+
+		if c.RowLimit > 0 && len(rows) >= c.RowLimit {
+			break
+		}
+	}
+
+	return rows
+}
+
+// csvFormatter writes stats as CSV, header with column names is written once.
+type csvFormatter struct {
+	w      *csv.Writer
+	header bool
+}
+
+// writeSample implements formatter interface.
+func (f *csvFormatter) writeSample(ts time.Time, res *stat.PGresult, rows []int) error {
+	if !f.header {
+		err := f.w.Write(append([]string{"timestamp"}, res.Cols...))
+		if err != nil {
+			return err
+		}
+		f.header = true
+	}
+
+	for _, i := range rows {
+		record := []string{ts.Format(time.RFC3339)}
+		for _, v := range res.Values[i] {
+			record = append(record, v.String)
+		}
+
+		err := f.w.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// flush implements formatter interface.
+func (f *csvFormatter) flush() error {
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// jsonFormatter writes stats as JSON objects, one object per row. Objects are written as JSON array or as
+// newline-delimited JSON (JSON Lines).
+type jsonFormatter struct {
+	w       io.Writer
+	array   bool // write objects as JSON array
+	written int  // number of written objects
+}
+
+// writeSample implements formatter interface.
+func (f *jsonFormatter) writeSample(ts time.Time, res *stat.PGresult, rows []int) error {
+	for _, i := range rows {
+		obj, err := marshalRow(ts, res, i)
+		if err != nil {
+			return err
+		}
+
+		var prefix string
+		if f.array {
+			prefix = ",\n"
+			if f.written == 0 {
+				prefix = "[\n"
+			}
+		}
+
+		_, err = fmt.Fprintf(f.w, "%s%s", prefix, obj)
+		if err != nil {
+			return err
+		}
+
+		if !f.array {
+			_, err = fmt.Fprintln(f.w)
+			if err != nil {
+				return err
+			}
+		}
+
+		f.written++
+	}
+
+	return nil
+}
+
+// flush implements formatter interface.
+func (f *jsonFormatter) flush() error {
+	if !f.array {
+		return nil
+	}
+
+	if f.written == 0 {
+		_, err := fmt.Fprintln(f.w, "[]")
+		return err
+	}
+
+	_, err := fmt.Fprint(f.w, "\n]\n")
+	return err
+}
+
+// marshalRow marshals row into JSON object with timestamp and values keyed by column names. Order of columns is kept.
+// NULL values are marshaled as null.
+func marshalRow(ts time.Time, res *stat.PGresult, row int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"timestamp":`)
+	data, err := json.Marshal(ts.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(data)
+
+	for i, name := range res.Cols {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if res.Values[row][i].Valid {
+			value = res.Values[row][i].String
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// markdownFormatter writes stats as Markdown table, header with column names is written once.
+type markdownFormatter struct {
+	w      io.Writer
+	header bool
+}
+
+// writeSample implements formatter interface.
+func (f *markdownFormatter) writeSample(ts time.Time, res *stat.PGresult, rows []int) error {
+	if !f.header {
+		cols := append([]string{"timestamp"}, res.Cols...)
+		_, err := fmt.Fprintf(f.w, "| %s |\n|%s\n", strings.Join(escapeMarkdown(cols), " | "), strings.Repeat("---|", len(cols)))
+		if err != nil {
+			return err
+		}
+		f.header = true
+	}
+
+	for _, i := range rows {
+		values := []string{ts.Format(time.RFC3339)}
+		for _, v := range res.Values[i] {
+			values = append(values, v.String)
+		}
+
+		_, err := fmt.Fprintf(f.w, "| %s |\n", strings.Join(escapeMarkdown(values), " | "))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flush implements formatter interface.
+func (f *markdownFormatter) flush() error {
+	return nil
+}
+
+// escapeMarkdown escapes characters which break Markdown table layout.
+func escapeMarkdown(values []string) []string {
+	r := strings.NewReplacer("|", `\|`, "\n", " ", "\r", "")
+
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = r.Replace(v)
+	}
+
+	return escaped
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_newFormatter(t *testing.T) {
+	testcases := []struct {
+		format string
+		want   string
+	}{
+		{format: "csv", want: "timestamp,datname,xact_commit\n2021-01-23T15:31:00Z,example,10\n2021-01-23T15:31:00Z,\"a,b\",\n"},
+		{format: "jsonl", want: `{"timestamp":"2021-01-23T15:31:00Z","datname":"example","xact_commit":"10"}` + "\n" +
+			`{"timestamp":"2021-01-23T15:31:00Z","datname":"a,b","xact_commit":null}` + "\n"},
+		{format: "json", want: "[\n" + `{"timestamp":"2021-01-23T15:31:00Z","datname":"example","xact_commit":"10"}` + ",\n" +
+			`{"timestamp":"2021-01-23T15:31:00Z","datname":"a,b","xact_commit":null}` + "\n]\n"},
+		{format: "markdown", want: "| timestamp | datname | xact_commit |\n|---|---|---|\n| 2021-01-23T15:31:00Z | example | 10 |\n| 2021-01-23T15:31:00Z | a,b |  |\n"},
+	}
+
+	res := stat.PGresult{
+		Valid: true, Ncols: 2, Nrows: 2,
+		Cols: []string{"datname", "xact_commit"},
+		Values: [][]sql.NullString{
+			{{String: "example", Valid: true}, {String: "10", Valid: true}},
+			{{String: "a,b", Valid: true}, {String: "", Valid: false}},
+		},
+	}
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.UTC)
+
+	for _, tc := range testcases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			f, err := newFormatter(tc.format, &buf)
+			assert.NoError(t, err)
+			assert.NoError(t, f.writeSample(ts, &res, []int{0, 1}))
+			assert.NoError(t, f.flush())
+			assert.Equal(t, tc.want, buf.String())
+		})
+	}
+
+	// Text format doesn't use formatter.
+	f, err := newFormatter("text", &bytes.Buffer{})
+	assert.NoError(t, err)
+	assert.Nil(t, f)
+
+	// Empty JSON array.
+	var buf bytes.Buffer
+	f, err = newFormatter("json", &buf)
+	assert.NoError(t, err)
+	assert.NoError(t, f.flush())
+	assert.Equal(t, "[]\n", buf.String())
+
+	// Unknown format.
+	_, err = newFormatter("invalid", &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func Test_selectRows(t *testing.T) {
+	res := stat.PGresult{
+		Valid: true, Ncols: 1, Nrows: 3,
+		Cols: []string{"name"},
+		Values: [][]sql.NullString{
+			{{String: "alfa", Valid: true}}, {{String: "bravo", Valid: true}}, {{String: "alpha", Valid: true}},
+		},
+	}
+
+	testcases := []struct {
+		config Config
+		want   []int
+	}{
+		{config: Config{}, want: []int{0, 1, 2}},
+		{config: Config{RowLimit: 2}, want: []int{0, 1}},
+		{config: Config{FilterColName: "name", FilterRE: regexp.MustCompile("^al")}, want: []int{0, 2}},
+		{config: Config{FilterColName: "name", FilterRE: regexp.MustCompile("^al"), RowLimit: 1}, want: []int{0}},
+		{config: Config{FilterColName: "unknown", FilterRE: regexp.MustCompile("^al")}, want: nil},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, selectRows(&res, tc.config))
+	}
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
+	"time"
+)
+
+// readMetadata reads metadata of the recording session which has been running at the specified time. If there is
+// no such session, metadata of the first session recorded after the time is returned. Returns nil if stats have been
+// recorded without metadata (e.g. by previous versions).
+func readMetadata(r *archive.Reader, start time.Time) (*archive.Metadata, error) {
+	r.SeekMetadata(start)
+
+	var meta *archive.Metadata
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("advance read position failed: %s", err)
+		}
+
+		name, ts, err := archive.ParseEntryName(hdr.Name)
+		if err != nil {
+			continue
+		}
+
+		// Sessions started after the time are not interesting if metadata has been found already.
+		if ts.After(start) && meta != nil {
+			break
+		}
+
+		if name != archive.MetadataEntryName {
+			if ts.After(start) {
+				break
+			}
+			continue
+		}
+
+		m := &archive.Metadata{}
+		err = json.NewDecoder(io.LimitReader(r, hdr.Size)).Decode(m)
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed: %s", hdr.Name, err)
+		}
+		meta = m
+
+		if ts.After(start) {
+			break
+		}
+	}
+
+	return meta, nil
+}
+
+// configureViews configures views accordingly to recorded metadata, hence views' layout corresponds to the recorded
+// stats, but not to the current version of pgcenter.
+func configureViews(views view.Views, meta *archive.Metadata) error {
+	if meta == nil {
+		return nil
+	}
+
+	return views.Configure(meta.Options)
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeMetadataTestArchive writes archive with two recording sessions, every session is started with metadata.
+func writeMetadataTestArchive(t *testing.T, filename string, ts time.Time, withIndex bool) {
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+
+	tw := tar.NewWriter(f)
+	var entries []archive.IndexEntry
+	for i := 0; i < 10; i++ {
+		sts := ts.Add(time.Duration(i) * time.Minute)
+		snapshot, err := f.Seek(0, io.SeekCurrent)
+		assert.NoError(t, err)
+
+		var names []string
+		var data [][]byte
+		if i == 0 || i == 5 {
+			meta, err := json.Marshal(archive.Metadata{Hostname: fmt.Sprintf("session%d", i), Views: map[string][]string{}})
+			assert.NoError(t, err)
+			names, data = append(names, archive.MetadataEntryName), append(data, meta)
+		}
+		names, data = append(names, "databases"), append(data, []byte(`{}`))
+
+		for j, name := range names {
+			entry := fmt.Sprintf("%s.%s.json", name, sts.Format("20060102T150405"))
+			assert.NoError(t, tw.WriteHeader(&tar.Header{Name: entry, Mode: 0644, Size: int64(len(data[j]))}))
+			_, err = tw.Write(data[j])
+			assert.NoError(t, err)
+			entries = append(entries, archive.IndexEntry{Name: entry, Snapshot: snapshot, Offset: -1, Size: int64(len(data[j]))})
+		}
+		assert.NoError(t, tw.Flush())
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, f.Close())
+
+	if withIndex {
+		idx, err := os.Create(archive.IndexName(filename))
+		assert.NoError(t, err)
+		assert.NoError(t, archive.WriteIndex(idx, entries))
+		assert.NoError(t, idx.Close())
+	}
+}
+
+func Test_readMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-report-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+
+	testcases := []struct {
+		start time.Duration
+		want  string
+	}{
+		{start: -time.Hour, want: "session0"},
+		{start: 0, want: "session0"},
+		{start: 3 * time.Minute, want: "session0"},
+		{start: 5 * time.Minute, want: "session5"},
+		{start: time.Hour, want: "session5"},
+	}
+
+	for _, withIndex := range []bool{false, true} {
+		filename := filepath.Join(dir, fmt.Sprintf("pgcenter.stat.%t.tar", withIndex))
+		writeMetadataTestArchive(t, filename, ts, withIndex)
+
+		for _, tc := range testcases {
+			r, err := archive.NewReader(filename)
+			assert.NoError(t, err)
+
+			meta, err := readMetadata(r, ts.Add(tc.start))
+			assert.NoError(t, err)
+			if assert.NotNil(t, meta) {
+				assert.Equal(t, tc.want, meta.Hostname)
+			}
+			assert.NoError(t, r.Close())
+		}
+	}
+
+	// Stats recorded without metadata.
+	filename := filepath.Join(dir, "pgcenter.stat.tar")
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+	tw := tar.NewWriter(f)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "databases." + ts.Format("20060102T150405") + ".json", Mode: 0644, Size: 2}))
+	_, err = tw.Write([]byte(`{}`))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, f.Close())
+
+	r, err := archive.NewReader(filename)
+	assert.NoError(t, err)
+	meta, err := readMetadata(r, ts)
+	assert.NoError(t, err)
+	assert.Nil(t, meta)
+	assert.NoError(t, r.Close())
+}
+
+func Test_configureViews(t *testing.T) {
+	// Views are not changed without metadata.
+	views := view.New()
+	assert.NoError(t, configureViews(views, nil))
+	assert.Equal(t, view.New(), views)
+
+	// Views are configured for recorded Postgres version.
+	meta := &archive.Metadata{
+		Postgres: stat.PostgresProperties{VersionNum: 90600, Version: "9.6.0"},
+		Options:  query.NewOptions(90600, "f", "off", 0),
+	}
+
+	views = view.New()
+	assert.NoError(t, configureViews(views, meta))
+
+	_, _, want := query.SelectStatDatabaseQuery(90600)
+	assert.Equal(t, want, views["databases"].DiffIntvl)
+	assert.NotEqual(t, "", views["databases"].Query)
+}
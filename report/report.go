@@ -2,9 +2,9 @@ package report
 
 import (
 	"archive/tar"
-	"encoding/json"
 	"fmt"
 	"github.com/lesovsky/pgcenter/internal/align"
+	"github.com/lesovsky/pgcenter/internal/archive"
 	"github.com/lesovsky/pgcenter/internal/stat"
 	"github.com/lesovsky/pgcenter/internal/view"
 	"io"
@@ -28,6 +28,12 @@ type Config struct {
 	RowLimit      int
 	TruncLimit    int
 	Rate          time.Duration
+	ViewsFile     string
+	Format        string
+	Summary       bool
+	CompareFile   string
+	CompareStart  time.Time
+	CompareEnd    time.Time
 }
 
 const (
@@ -37,49 +43,128 @@ const (
 
 // RunMain is the main entry point for 'pgcenter report' sub-command.
 func RunMain(c Config) error {
-	app := newApp(c)
+	// Load predefined views, views of system stats and user-defined views.
+	views := view.New()
+	for k, v := range view.NewSystem() {
+		views[k] = v
+	}
+	for k, v := range view.NewASH() {
+		views[k] = v
+	}
+
+	err := views.LoadCustom(c.ViewsFile, archive.ReservedEntryNames)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := views[c.ReportType]; !ok {
+		return fmt.Errorf("unknown report type: %s", c.ReportType)
+	}
+
+	app := newApp(c, views)
+
+	app.formatter, err = newFormatter(c.Format, app.writer)
+	if err != nil {
+		return err
+	}
 
 	// Print report description if requested.
 	if c.Describe {
+		if app.view.Custom {
+			return describeCustomReport(app.writer, app.view)
+		}
 		return describeReport(app.writer, c.ReportType)
 	}
 
-	// Open file with statistics.
-	f, err := os.Open(c.InputFile)
+	// Read metadata of recording session, views should be configured in the same way as they were recorded.
+	mr, err := archive.NewReader(c.InputFile)
 	if err != nil {
 		return err
 	}
 
+	meta, err := readMetadata(mr, c.TsStart)
+	_ = mr.Close()
+	if err != nil {
+		return err
+	}
+
+	err = configureViews(views, meta)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := views[c.ReportType]; !ok {
+		return fmt.Errorf("report type %s is not supported by recorded Postgres %s", c.ReportType, meta.Postgres.Version)
+	}
+	app.view = views[c.ReportType]
+
+	// Samples of active sessions are written once per recording interval, after they have been taken.
+	var slack time.Duration
+	if c.ReportType == ashReportType {
+		if meta == nil || meta.ASHInterval == 0 {
+			return fmt.Errorf("no samples of active sessions found in %s", c.InputFile)
+		}
+		slack = meta.Interval
+	}
+
+	// Open files with statistics.
+	ar, err := archive.NewReader(c.InputFile)
+	if err != nil {
+		return err
+	}
+	ar.Seek(c.ReportType, c.TsStart, c.TsEnd.Add(slack))
+
 	defer func() {
-		err := f.Close()
+		err := ar.Close()
 		if err != nil {
 			fmt.Printf("close file descriptor failed: %s, ignore", err)
 		}
 	}()
 
-	// Print report header.
-	err = printReportHeader(app.writer, app.config)
-	if err != nil {
-		return err
+	// Print report header, machine-readable formats are printed without header.
+	if app.formatter == nil {
+		err = printReportHeader(app.writer, app.config, meta)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Initialize tar reader.
-	tr := tar.NewReader(f)
+	// Compare with stats from another file (or another interval of the same file).
+	if c.CompareFile != "" {
+		cr, err := archive.NewReader(c.CompareFile)
+		if err != nil {
+			return err
+		}
+		cr.Seek(c.ReportType, c.CompareStart, c.CompareEnd)
+
+		defer func() {
+			err := cr.Close()
+			if err != nil {
+				fmt.Printf("close file descriptor failed: %s, ignore", err)
+			}
+		}()
+
+		return app.doCompare(ar, cr)
+	}
+
+	if c.ReportType == ashReportType {
+		return app.doASH(ar, meta.Interval, meta.ASHInterval)
+	}
 
 	// Start printing report.
-	return app.doReport(tr)
+	return app.doReport(ar)
 }
 
 // app defines application container with runtime dependencies.
 type app struct {
-	config Config
-	view   view.View
-	writer io.Writer
+	config    Config
+	view      view.View
+	writer    io.Writer
+	formatter formatter // formatter used for machine-readable formats, nil for text format
 }
 
 // newApp creates new 'pgcenter record' app.
-func newApp(config Config) *app {
-	views := view.New()
+func newApp(config Config, views view.Views) *app {
 	v := views[config.ReportType]
 
 	return &app{
@@ -90,15 +175,115 @@ func newApp(config Config) *app {
 }
 
 // Read statistics file and create a report based on report settings
-func (app *app) doReport(r *tar.Reader) error {
-	var prevStat stat.PGresult
-	var prevTs time.Time
+func (app *app) doReport(r entryReader) error {
 	var linesPrinted = repeatHeaderAfter // initial value means print header at the beginning of all output
-	var orderConfigured = false          // flag tells about order is not configured.
 
 	c := app.config
 	v := app.view
 
+	// Summary aggregates all samples and printed at the end.
+	var sum *summary
+	var sumTs time.Time
+	if c.Summary {
+		var err error
+		sum, err = newSummary(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := app.readSamples(r, c.TsStart, c.TsEnd, &v, func(ts time.Time, diffStat, currStat, prevStat stat.PGresult, itv float64) error {
+		switch {
+		case sum != nil:
+			// Aggregate the stats, summary is printed when all samples are read.
+			sumTs = ts
+			return sum.add(currStat, prevStat, itv, c)
+		case app.formatter != nil:
+			// Write the stats in machine-readable format.
+			err := app.formatter.writeSample(ts, &diffStat, selectRows(&diffStat, c))
+			if err != nil {
+				return err
+			}
+		default:
+			// Format the stat
+			formatStatSample(&diffStat, &v, c)
+
+			// print header after every Nth lines
+			var err error
+			linesPrinted, err = printStatHeader(app.writer, linesPrinted, v)
+			if err != nil {
+				return err
+			}
+
+			// print the stats - calculated delta between previous and current stats snapshots
+			n, err := printStatSample(app.writer, &diffStat, v, c, ts)
+			if err != nil {
+				return err
+			}
+			linesPrinted += n
+		}
+
+		return nil
+	}, func(ts time.Time, reason string) error {
+		// Gaps are not shown in summary, only deltas across them are not aggregated.
+		if sum != nil {
+			return nil
+		}
+
+		// Don't mix warnings with machine-readable output.
+		w := app.writer
+		if app.formatter != nil {
+			w = os.Stderr
+		}
+
+		_, err := fmt.Fprintf(w, "WARNING: gap in recorded stats since %s: %s\n", ts.Format("2006-01-02 15:04:05"), reason)
+		if err != nil {
+			return err
+		}
+
+		// Print header again after the gap.
+		linesPrinted = repeatHeaderAfter
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if sum != nil {
+		return app.printSummary(sum, v, sumTs)
+	}
+
+	if app.formatter != nil {
+		return app.formatter.flush()
+	}
+
+	return nil
+}
+
+// entryReader defines reader of stats archive entries.
+type entryReader interface {
+	io.Reader
+	Next() (*tar.Header, error)
+}
+
+// sampleFunc defines handler of the delta calculated between two consecutive stats snapshots. The curr and prev are
+// the snapshots the delta is calculated from, the itv is the number of rate intervals between snapshots.
+type sampleFunc func(ts time.Time, delta, curr, prev stat.PGresult, itv float64) error
+
+// gapFunc defines handler of the gap in recorded stats. The reason describes why stats were not recorded.
+type gapFunc func(ts time.Time, reason string) error
+
+// readSamples reads stats snapshots of requested report type recorded within the interval, calculates deltas between
+// consecutive snapshots and passes them to the handler. Order of the view is configured when columns become known.
+// Deltas are not calculated across gaps in recorded stats, gaps are passed to the gap handler (if specified).
+func (app *app) readSamples(r entryReader, start, end time.Time, v *view.View, fn sampleFunc, gap gapFunc) error {
+	var prevStat stat.PGresult
+	var prevTs time.Time
+	var orderConfigured = false // flag tells about order is not configured.
+	var dec archive.Decoder     // decoder of stats written into compressed archives
+
+	c := app.config
+
 	// read files headers continuously, read stats files requested by user and skip others.
 	for {
 		hdr, err := r.Next()
@@ -108,22 +293,57 @@ func (app *app) doReport(r *tar.Reader) error {
 			return fmt.Errorf("advance read position failed: %s", err)
 		}
 
+		// Stats recorded before and after the gap can't be compared, start over from the next snapshot.
+		if isFilenameOK(hdr.Name, archive.GapEntryName) == nil {
+			ts, err := isFilenameTimestampOK(hdr.Name, start, end)
+			if err != nil {
+				continue
+			}
+
+			prevStat = stat.PGresult{}
+
+			if gap != nil {
+				res, err := archive.ReadResult(r, hdr.Size)
+				if err != nil {
+					return err
+				}
+
+				var reason string
+				if res.Nrows > 0 && res.Ncols > 0 {
+					reason = res.Values[0][0].String
+				}
+
+				err = gap(ts, reason)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
 		// Check filename - it has valid format and corresponds to requested report type.
 		err = isFilenameOK(hdr.Name, c.ReportType)
 		if err != nil {
 			continue
 		}
 
+		delta := archive.IsDeltaEntry(hdr.Name)
+
 		// Check timestamp in filename, is it correct and is in requested report interval.
-		ts, err := isFilenameTimestampOK(hdr.Name, c.TsStart, c.TsEnd)
+		ts, err := isFilenameTimestampOK(hdr.Name, start, end)
 		if err != nil {
+			// Stats out of the interval might be required for restoring the next stats written as deltas.
+			err := dec.Skip(r, hdr.Size, delta)
+			if err != nil {
+				return fmt.Errorf("read %s failed: %s", hdr.Name, err)
+			}
 			continue
 		}
 
 		// Read stats from file.
-		currStat, err := readFileStat(r, hdr.Size)
+		currStat, err := dec.Read(r, hdr.Size, delta)
 		if err != nil {
-			return err
+			return fmt.Errorf("read %s failed: %s", hdr.Name, err)
 		}
 
 		// if previous stats snapshot is not defined, copy current to previous.
@@ -137,8 +357,14 @@ func (app *app) doReport(r *tar.Reader) error {
 		// Calculate time interval.
 		interval := ts.Sub(prevTs)
 		if c.Rate > interval {
+			// Don't mix warnings with machine-readable output.
+			w := app.writer
+			if app.formatter != nil {
+				w = os.Stderr
+			}
+
 			_, err := fmt.Fprintf(
-				app.writer,
+				w,
 				"WARNING: specified rate longer than stats snapshots interval, adjusting it to %s\n",
 				interval.String(),
 			)
@@ -158,26 +384,15 @@ func (app *app) doReport(r *tar.Reader) error {
 		}
 
 		// Calculate delta between current and previous stats snapshots.
-		diffStat, err := countDiff(currStat, prevStat, int(interval/c.Rate), v)
-		if err != nil {
-			return err
-		}
-
-		// Format the stat
-		formatStatSample(&diffStat, &v, c)
-
-		// print header after every Nth lines
-		linesPrinted, err = printStatHeader(app.writer, linesPrinted, v)
+		diffStat, err := countDiff(currStat, prevStat, int(interval/c.Rate), *v)
 		if err != nil {
 			return err
 		}
 
-		// print the stats - calculated delta between previous and current stats snapshots
-		n, err := printStatSample(app.writer, &diffStat, v, c, ts)
+		err = fn(ts, diffStat, currStat, prevStat, float64(interval)/float64(c.Rate))
 		if err != nil {
 			return err
 		}
-		linesPrinted += n
 
 		// Swap previous with current
 		prevStat = currStat
@@ -227,24 +442,6 @@ func isFilenameTimestampOK(name string, start, end time.Time) (time.Time, error)
 	return ts, nil
 }
 
-// readFileStat reads content of tar file, unmarshal data and return stat object.
-func readFileStat(r *tar.Reader, bufsz int64) (stat.PGresult, error) {
-	data := make([]byte, bufsz)
-
-	if _, err := io.ReadFull(r, data); err != nil {
-		return stat.PGresult{}, err
-	}
-
-	// initialize an empty struct and unmarshal data from the buffer
-	res := stat.PGresult{}
-	err := json.Unmarshal(data, &res)
-	if err != nil {
-		return stat.PGresult{}, err
-	}
-
-	return res, nil
-}
-
 // countDiff compares two stat samples and produce differential sample.
 func countDiff(curr, prev stat.PGresult, interval int, v view.View) (stat.PGresult, error) {
 	var diff stat.PGresult
@@ -284,8 +481,8 @@ func formatStatSample(d *stat.PGresult, view *view.View, c Config) {
 	view.Aligned = true
 }
 
-// printReportHeader prints report header.
-func printReportHeader(w io.Writer, c Config) error {
+// printReportHeader prints report header. Description of recording session is printed if metadata is available.
+func printReportHeader(w io.Writer, c Config, meta *archive.Metadata) error {
 	tmpl := "INFO: reading from %s\n" +
 		"INFO: report %s\n" +
 		"INFO: start from: %s, to: %s, with rate: %s\n"
@@ -297,6 +494,23 @@ func printReportHeader(w io.Writer, c Config) error {
 		c.Rate.String(),
 	)
 
+	if meta != nil {
+		msg += fmt.Sprintf("INFO: recorded on %s by %s, Postgres %s (recovery: %s)\n",
+			meta.Hostname,
+			meta.Version,
+			meta.Postgres.Version,
+			meta.Postgres.Recovery,
+		)
+	}
+
+	if c.CompareFile != "" {
+		msg += fmt.Sprintf("INFO: compare with %s, start from: %s, to: %s\n",
+			c.CompareFile,
+			c.CompareStart.Format("2006-01-02 15:04:05 MST"),
+			c.CompareEnd.Format("2006-01-02 15:04:05 MST"),
+		)
+	}
+
 	_, err := fmt.Fprint(w, msg)
 	if err != nil {
 		return err
@@ -429,6 +643,10 @@ func describeReport(w io.Writer, report string) error {
 		"statements_io":      pgStatStatementsIODescription,
 		"statements_local":   pgStatStatementsTempDescription,
 		"statements_temp":    pgStatStatementsLocalDescription,
+		"sysstat":            sysstatDescription,
+		"diskstats":          diskstatsDescription,
+		"netdev":             netdevDescription,
+		"ash":                ashDescription,
 	}
 
 	if description, ok := m[report]; ok {
@@ -445,3 +663,9 @@ func describeReport(w io.Writer, report string) error {
 
 	return nil
 }
+
+// describeCustomReport prints description of user-defined view.
+func describeCustomReport(w io.Writer, v view.View) error {
+	_, err := fmt.Fprintf(w, "User-defined view %s: %s\n\nQuery:\n%s\n", v.Name, v.Msg, v.QueryTmpl)
+	return err
+}
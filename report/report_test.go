@@ -3,15 +3,19 @@ package report
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/lesovsky/pgcenter/internal/align"
+	"github.com/lesovsky/pgcenter/internal/archive"
 	"github.com/lesovsky/pgcenter/internal/stat"
 	"github.com/lesovsky/pgcenter/internal/view"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 	"time"
@@ -140,7 +144,7 @@ func Test_app_doReport(t *testing.T) {
 		tc.config.TsStart = ts
 		tc.config.TsEnd = te
 
-		app := newApp(tc.config)
+		app := newApp(tc.config, view.New())
 		var buf bytes.Buffer
 		app.writer = &buf
 
@@ -158,6 +162,105 @@ func Test_app_doReport(t *testing.T) {
 	}
 }
 
+func Test_app_readSamples_gap(t *testing.T) {
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+
+	// Write samples of 'tables' view, the third sample is replaced by gap marker.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i, name := range []string{"tables", "tables", "gap", "tables", "tables"} {
+		res := newSummaryTestResult([2]string{"t1", fmt.Sprintf("%d", i*10)})
+		if name == "gap" {
+			res = stat.PGresult{Valid: true, Ncols: 1, Nrows: 1, Cols: []string{"reason"}, Values: [][]sql.NullString{{{String: "connection lost", Valid: true}}}}
+		}
+
+		data, err := json.Marshal(res)
+		assert.NoError(t, err)
+		sts := ts.Add(time.Duration(i) * time.Second)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("%s.%s.json", name, sts.Format("20060102T150405")), Mode: 0644, Size: int64(len(data)), ModTime: sts}))
+		_, err = tw.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+
+	app := &app{config: Config{ReportType: "tables", Rate: time.Second}, writer: ioutil.Discard}
+	v := view.View{Name: "tables", DiffIntvl: [2]int{1, 1}, ColsWidth: map[int]int{}}
+
+	var samples []string
+	var gaps []string
+	err := app.readSamples(tar.NewReader(&buf), ts, ts.Add(time.Hour), &v,
+		func(ts time.Time, delta, _, _ stat.PGresult, _ float64) error {
+			samples = append(samples, ts.Format("15:04:05")+" "+delta.Values[0][1].String)
+			return nil
+		},
+		func(ts time.Time, reason string) error {
+			gaps = append(gaps, ts.Format("15:04:05")+" "+reason)
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+
+	// Delta across the gap is not calculated.
+	assert.Equal(t, []string{"15:31:01 10", "15:31:04 10"}, samples)
+	assert.Equal(t, []string{"15:31:02 connection lost"}, gaps)
+}
+
+func Test_app_readSamples_compressed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-report-testing")
+	assert.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dir)) }()
+
+	filename := filepath.Join(dir, "pgcenter.stat.tar.gz")
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+
+	// Write keyframe followed by deltas, every snapshot is written as separate gzip member.
+	ts := time.Date(2021, 1, 23, 15, 31, 0, 0, time.Now().Location())
+	prev := newSummaryTestResult([2]string{"t1", "0"}, [2]string{"t2", "0"})
+	for i := 0; i < 4; i++ {
+		var v interface{} = prev
+		ext := "json"
+		if i > 0 {
+			curr := newSummaryTestResult([2]string{"t1", fmt.Sprintf("%d", i*10)}, [2]string{"t2", "0"})
+			d, ok := stat.NewResultDelta(prev, curr, 0)
+			assert.True(t, ok)
+			v, ext, prev = d, "delta", curr
+		}
+
+		data, err := json.Marshal(v)
+		assert.NoError(t, err)
+
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+		sts := ts.Add(time.Duration(i) * time.Second)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("tables.%s.%s", sts.Format("20060102T150405"), ext), Mode: 0644, Size: int64(len(data))}))
+		_, err = tw.Write(data)
+		assert.NoError(t, err)
+		assert.NoError(t, tw.Flush())
+		assert.NoError(t, gz.Close())
+	}
+	assert.NoError(t, f.Close())
+
+	// Keyframe is out of requested interval, but it is used for restoring stats.
+	r, err := archive.NewReader(filename)
+	assert.NoError(t, err)
+
+	app := &app{config: Config{ReportType: "tables", Rate: time.Second}, writer: ioutil.Discard}
+	v := view.View{Name: "tables", DiffIntvl: [2]int{1, 1}, ColsWidth: map[int]int{}}
+
+	var samples []string
+	err = app.readSamples(r, ts.Add(time.Second), ts.Add(time.Hour), &v, func(ts time.Time, delta, _, _ stat.PGresult, _ float64) error {
+		for _, row := range delta.Values {
+			samples = append(samples, ts.Format("15:04:05")+" "+row[0].String+" "+row[1].String)
+		}
+		return nil
+	}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+
+	assert.Equal(t, []string{"15:31:02 t1 10", "15:31:02 t2 0", "15:31:03 t1 10", "15:31:03 t2 0"}, samples)
+}
+
 func Test_isFilenameOK(t *testing.T) {
 	testcases := []struct {
 		valid  bool
@@ -236,7 +339,7 @@ func Test_readFileStat(t *testing.T) {
 					assert.Fail(t, "unexpected error", err)
 				}
 
-				got, err := readFileStat(r, hdr.Size)
+				got, err := archive.ReadResult(r, hdr.Size)
 				if tc.valid {
 					assert.NoError(t, err)
 					assert.NotNil(t, got.Values)
@@ -384,8 +487,18 @@ INFO: start from: 2021-01-18 05:00:00 +05, to: 2021-01-18 06:00:00 +05, with rat
 `
 
 	var buf bytes.Buffer
-	assert.NoError(t, printReportHeader(&buf, c))
+	assert.NoError(t, printReportHeader(&buf, c, nil))
 	assert.Equal(t, want, buf.String())
+
+	// Description of recording session is printed if metadata is available.
+	meta := &archive.Metadata{
+		Hostname: "example", Version: "pgcenter v0.9.0",
+		Postgres: stat.PostgresProperties{Version: "13.0", Recovery: "f"},
+	}
+
+	buf.Reset()
+	assert.NoError(t, printReportHeader(&buf, c, meta))
+	assert.Equal(t, want+"INFO: recorded on example by pgcenter v0.9.0, Postgres 13.0 (recovery: f)\n", buf.String())
 }
 
 func Test_printStatHeader(t *testing.T) {
@@ -520,3 +633,10 @@ func Test_describeReport(t *testing.T) {
 	}
 
 }
+
+func Test_describeCustomReport(t *testing.T) {
+	var buf bytes.Buffer
+	v := view.View{Name: "queue", Msg: "Show queues statistics", QueryTmpl: "SELECT 1", Custom: true}
+	assert.NoError(t, describeCustomReport(&buf, v))
+	assert.Equal(t, "User-defined view queue: Show queues statistics\n\nQuery:\nSELECT 1\n", buf.String())
+}
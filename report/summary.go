@@ -0,0 +1,303 @@
+package report
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// summaryCols defines names of columns with aggregated values printed in summary report.
+var summaryCols = []string{"column", "total", "avg", "min", "max", "p95"}
+
+// summary aggregates stats samples over the whole report interval. Only columns with cumulative counters (which
+// are diffed between samples) are aggregated, other columns keep values from the latest sample.
+type summary struct {
+	view      view.View              // view used for diffing samples
+	cols      []string               // names of columns
+	rows      map[string]*summaryRow // aggregated rows, key is the value of view's unique key column
+	keys      []string               // keys of rows in order of their appearance
+	samples   int                    // number of aggregated samples
+	intervals float64                // number of rate intervals covered by aggregated samples
+}
+
+// summaryRow defines aggregated values of the single row.
+type summaryRow struct {
+	values []sql.NullString // values of the row from the latest sample
+	series []*series        // aggregated values of diffed columns, nil for other columns
+}
+
+// series defines values of the single column collected across samples. Zero values are only counted, because
+// most rows are idle most of the time.
+type series struct {
+	total  float64   // sum of raw deltas
+	zeros  int       // number of zero rates
+	values []float64 // non-zero rates
+}
+
+// newSummary creates summary for the view.
+func newSummary(v view.View) (*summary, error) {
+	if v.DiffIntvl == [2]int{0, 0} {
+		return nil, fmt.Errorf("summary is not supported for %s report, it has no cumulative counters", v.Name)
+	}
+
+	return &summary{view: v, rows: map[string]*summaryRow{}}, nil
+}
+
+// add aggregates the sample. Raw deltas between current and previous samples are aggregated, rows missing in the
+// previous sample don't have deltas and are not aggregated. The itv is the number of rate intervals between samples,
+// rates of the sample are calculated by dividing deltas by it.
+func (s *summary) add(curr, prev stat.PGresult, itv float64, c Config) error {
+	// Rates calculated by countDiff are integers, use raw deltas to not lose slowly changing counters.
+	delta, err := countDiff(curr, prev, 1, s.view)
+	if err != nil {
+		return err
+	}
+
+	if s.cols == nil {
+		s.cols = delta.Cols
+	}
+
+	ukey, intvl := s.view.UniqueKey, s.view.DiffIntvl
+
+	known := map[string]bool{}
+	for _, row := range prev.Values {
+		known[row[ukey].String] = true
+	}
+
+	filterIdx := -1
+	if c.FilterColName != "" {
+		if idx, ok := getColumnIndex(delta.Cols, c.FilterColName); ok {
+			filterIdx = idx
+		}
+	}
+
+	for _, row := range delta.Values {
+		key := row[ukey].String
+		if !known[key] {
+			continue
+		}
+
+		if c.FilterColName != "" && (filterIdx < 0 || !c.FilterRE.MatchString(row[filterIdx].String)) {
+			continue
+		}
+
+		r, ok := s.rows[key]
+		if !ok {
+			r = &summaryRow{series: make([]*series, len(row))}
+			for i := intvl[0]; i <= intvl[1] && i < len(row); i++ {
+				r.series[i] = &series{}
+			}
+			s.rows[key] = r
+			s.keys = append(s.keys, key)
+		}
+
+		r.values = row
+
+		for i, ser := range r.series {
+			if ser == nil {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				continue
+			}
+
+			ser.total += v
+			if v == 0 {
+				ser.zeros++
+			} else {
+				ser.values = append(ser.values, v/itv)
+			}
+		}
+	}
+
+	s.samples++
+	s.intervals += itv
+
+	return nil
+}
+
+// rate returns average rate of the column's values of the row over the whole aggregated interval.
+func (s *summary) rate(key string, col int) (float64, bool) {
+	r, ok := s.rows[key]
+	if !ok || s.intervals == 0 || col >= len(r.series) || r.series[col] == nil {
+		return 0, false
+	}
+
+	return r.series[col].total / s.intervals, true
+}
+
+// result returns aggregated rows ranked by the order column and limited by the row limit. Every aggregated column
+// of the row is returned as separate line which contains values of not aggregated columns, name of the aggregated
+// column, its total, average, min, max and 95th percentile values.
+func (s *summary) result(orderKey int, desc bool, limit int) stat.PGresult {
+	keys := make([]string, len(s.keys))
+	copy(keys, s.keys)
+
+	// Rank rows by total of the order column, or by its value if the column is not aggregated.
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := s.rows[keys[i]], s.rows[keys[j]]
+		if orderKey < 0 || orderKey >= len(a.values) {
+			return false
+		}
+
+		if a.series[orderKey] != nil {
+			if desc {
+				return a.series[orderKey].total > b.series[orderKey].total
+			}
+			return a.series[orderKey].total < b.series[orderKey].total
+		}
+
+		if desc {
+			return a.values[orderKey].String > b.values[orderKey].String
+		}
+		return a.values[orderKey].String < b.values[orderKey].String
+	})
+
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	// Columns of the result: not aggregated columns followed by aggregates.
+	var res stat.PGresult
+	var plain []int
+	for i, name := range s.cols {
+		if i < s.view.DiffIntvl[0] || i > s.view.DiffIntvl[1] {
+			plain = append(plain, i)
+			res.Cols = append(res.Cols, name)
+		}
+	}
+	res.Cols = append(res.Cols, summaryCols...)
+
+	for _, key := range keys {
+		r := s.rows[key]
+		for i, ser := range r.series {
+			if ser == nil {
+				continue
+			}
+
+			var values []sql.NullString
+			for _, idx := range plain {
+				values = append(values, r.values[idx])
+			}
+
+			min, max, avg, p95 := ser.stats()
+			for _, v := range []string{
+				s.cols[i], formatSummaryValue(ser.total), formatSummaryValue(avg),
+				formatSummaryValue(min), formatSummaryValue(max), formatSummaryValue(p95),
+			} {
+				values = append(values, sql.NullString{String: v, Valid: true})
+			}
+
+			res.Values = append(res.Values, values)
+		}
+	}
+
+	res.Ncols = len(res.Cols)
+	res.Nrows = len(res.Values)
+	res.Valid = true
+
+	return res
+}
+
+// stats returns min, max, average and 95th percentile (nearest-rank) of collected values.
+func (s *series) stats() (float64, float64, float64, float64) {
+	n := s.zeros + len(s.values)
+	if n == 0 {
+		return 0, 0, 0, 0
+	}
+
+	values := make([]float64, len(s.values))
+	copy(values, s.values)
+	sort.Float64s(values)
+
+	// Zero values are placed into the sorted sequence accordingly to their count.
+	neg := sort.SearchFloat64s(values, 0)
+	nth := func(i int) float64 {
+		switch {
+		case i < neg:
+			return values[i]
+		case i < neg+s.zeros:
+			return 0
+		default:
+			return values[i-s.zeros]
+		}
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	rank := int(math.Ceil(0.95*float64(n))) - 1
+	return nth(0), nth(n - 1), sum / float64(n), nth(rank)
+}
+
+// formatSummaryValue formats aggregated value, integers are printed without fractional part.
+func formatSummaryValue(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// printSummary prints aggregated stats in requested format.
+func (app *app) printSummary(s *summary, v view.View, ts time.Time) error {
+	res := s.result(v.OrderKey, v.OrderDesc, app.config.RowLimit)
+
+	if app.formatter == nil {
+		var order string
+		if v.OrderKey >= 0 && v.OrderKey < len(s.cols) {
+			order = s.cols[v.OrderKey]
+		}
+
+		_, err := fmt.Fprintf(app.writer, "INFO: summary of %d samples, ranked by %s\n", s.samples, order)
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.printAggregated(res, ts)
+}
+
+// printAggregated prints aggregated stats in requested format. Filter and limit are not applied, because they are
+// already applied during aggregation.
+func (app *app) printAggregated(res stat.PGresult, ts time.Time) error {
+	if app.formatter != nil {
+		rows := make([]int, res.Nrows)
+		for i := range rows {
+			rows[i] = i
+		}
+
+		err := app.formatter.writeSample(ts, &res, rows)
+		if err != nil {
+			return err
+		}
+
+		return app.formatter.flush()
+	}
+
+	if res.Nrows == 0 {
+		return nil
+	}
+
+	c := app.config
+	c.FilterColName, c.RowLimit = "", 0
+
+	sv := view.View{ColsWidth: map[int]int{}}
+	formatStatSample(&res, &sv, c)
+
+	_, err := printStatHeader(app.writer, repeatHeaderAfter, sv)
+	if err != nil {
+		return err
+	}
+
+	_, err = printStatSample(app.writer, &res, sv, c, ts)
+	return err
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// newSummaryTestResult creates result with 'relname' and 'seq_scan' columns.
+func newSummaryTestResult(rows ...[2]string) stat.PGresult {
+	res := stat.PGresult{Valid: true, Ncols: 2, Nrows: len(rows), Cols: []string{"relname", "seq_scan"}}
+	for _, r := range rows {
+		res.Values = append(res.Values, []sql.NullString{{String: r[0], Valid: true}, {String: r[1], Valid: true}})
+	}
+	return res
+}
+
+func Test_summary(t *testing.T) {
+	v := view.View{Name: "tables", DiffIntvl: [2]int{1, 1}, UniqueKey: 0, OrderKey: 1, OrderDesc: true}
+
+	s, err := newSummary(v)
+	assert.NoError(t, err)
+
+	prev := newSummaryTestResult([2]string{"t1", "0"}, [2]string{"t2", "0"})
+
+	// The 't3' is missing in previous sample and has no delta, it is not aggregated.
+	assert.NoError(t, s.add(newSummaryTestResult([2]string{"t1", "1"}, [2]string{"t2", "10"}, [2]string{"t3", "500"}), prev, 1, Config{}))
+	s.add(newSummaryTestResult([2]string{"t1", "0"}, [2]string{"t2", "20"}), prev, 2, Config{})
+	assert.NoError(t, s.add(newSummaryTestResult([2]string{"t1", "3"}, [2]string{"t2", "0"}), prev, 1, Config{}))
+	assert.Equal(t, 3, s.samples)
+
+	res := s.result(v.OrderKey, v.OrderDesc, 0)
+	assert.Equal(t, []string{"relname", "column", "total", "avg", "min", "max", "p95"}, res.Cols)
+	assert.Equal(t, 2, res.Nrows)
+
+	want := [][]string{
+		{"t2", "seq_scan", "30", "6.67", "0", "10", "10"},
+		{"t1", "seq_scan", "4", "1.33", "0", "3", "3"},
+	}
+	for i, row := range want {
+		for j, value := range row {
+			assert.Equal(t, value, res.Values[i][j].String)
+		}
+	}
+
+	// Ascending order and limit.
+	res = s.result(v.OrderKey, false, 1)
+	assert.Equal(t, 1, res.Nrows)
+	assert.Equal(t, "t1", res.Values[0][0].String)
+
+	// Slowly changing counter, recorded with interval longer than rate.
+	s, err = newSummary(v)
+	assert.NoError(t, err)
+	assert.NoError(t, s.add(newSummaryTestResult([2]string{"t1", "59"}), prev, 60, Config{}))
+	res = s.result(v.OrderKey, v.OrderDesc, 0)
+	for j, value := range []string{"t1", "seq_scan", "59", "0.98", "0.98", "0.98", "0.98"} {
+		assert.Equal(t, value, res.Values[0][j].String)
+	}
+	rate, ok := s.rate("t1", 1)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.983, rate, 0.001)
+
+	// Filter.
+	s, err = newSummary(v)
+	assert.NoError(t, err)
+	assert.NoError(t, s.add(newSummaryTestResult([2]string{"t1", "1"}, [2]string{"t2", "10"}), prev, 1, Config{FilterColName: "relname", FilterRE: regexp.MustCompile("1$")}))
+	res = s.result(v.OrderKey, v.OrderDesc, 0)
+	assert.Equal(t, 1, res.Nrows)
+	assert.Equal(t, "t1", res.Values[0][0].String)
+
+	// Views without counters are not supported.
+	_, err = newSummary(view.View{Name: "activity"})
+	assert.Error(t, err)
+}
+
+func Test_series_stats(t *testing.T) {
+	testcases := []struct {
+		s                  series
+		min, max, avg, p95 float64
+	}{
+		{s: series{}},
+		{s: series{zeros: 19, values: []float64{100}}, min: 0, max: 100, avg: 5, p95: 0},
+		{s: series{zeros: 18, values: []float64{100, -2}}, min: -2, max: 100, avg: 4.9, p95: 0},
+		{s: series{values: []float64{5, 1, 4, 2, 3}}, min: 1, max: 5, avg: 3, p95: 5},
+	}
+
+	for _, tc := range testcases {
+		min, max, avg, p95 := tc.s.stats()
+		assert.Equal(t, tc.min, min)
+		assert.Equal(t, tc.max, max)
+		assert.InDelta(t, tc.avg, avg, 0.001)
+		assert.Equal(t, tc.p95, p95)
+	}
+}
+
+func Test_app_printSummary(t *testing.T) {
+	v := view.View{Name: "tables", DiffIntvl: [2]int{1, 1}, UniqueKey: 0, OrderKey: 1, OrderDesc: true}
+	s, err := newSummary(v)
+	assert.NoError(t, err)
+
+	prev := newSummaryTestResult([2]string{"t1", "0"})
+	assert.NoError(t, s.add(newSummaryTestResult([2]string{"t1", "2"}), prev, 1, Config{}))
+
+	var buf bytes.Buffer
+	app := &app{config: Config{TruncLimit: 32}, view: v, writer: &buf}
+	assert.NoError(t, app.printSummary(s, v, time.Date(2021, 1, 23, 15, 31, 0, 0, time.UTC)))
+	assert.Contains(t, buf.String(), "INFO: summary of 1 samples, ranked by seq_scan\n")
+	assert.Contains(t, buf.String(), "15:31:00 t1")
+
+	buf.Reset()
+	app.formatter, err = newFormatter("csv", &buf)
+	assert.NoError(t, err)
+	assert.NoError(t, app.printSummary(s, v, time.Date(2021, 1, 23, 15, 31, 0, 0, time.UTC)))
+	assert.Equal(t, "timestamp,relname,column,total,avg,min,max,p95\n2021-01-23T15:31:00Z,t1,seq_scan,2,2,2,2,2\n", buf.String())
+}
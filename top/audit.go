@@ -0,0 +1,214 @@
+package top
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/query"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// AuditPostgresLog defines audit records are written into Postgres log using RAISE LOG.
+	AuditPostgresLog = "log"
+	// AuditPostgresNotify defines audit records are sent to Postgres notification channel using pg_notify().
+	AuditPostgresNotify = "notify"
+
+	// auditChannel defines name of the notification channel where audit records are sent.
+	auditChannel = "pgcenter_audit"
+
+	// maxNotifyPayload defines maximum size of notification payload accepted by pg_notify().
+	maxNotifyPayload = 7999
+)
+
+// auditRecord describes administrative action performed from 'top', records are written as JSON lines.
+type auditRecord struct {
+	Time     time.Time     `json:"time"`
+	OSUser   string        `json:"os_user"`           // name of the user who has been running pgcenter
+	Hostname string        `json:"hostname"`          // name of the host where pgcenter has been running
+	DBHost   string        `json:"db_host"`           // Postgres host (or socket directory) pgcenter connected to
+	DBPort   uint16        `json:"db_port"`           // Postgres port
+	DBUser   string        `json:"db_user"`           // Postgres user pgcenter connected as
+	Database string        `json:"database"`          // database pgcenter connected to
+	Action   string        `json:"action"`            // performed action
+	Targets  []auditTarget `json:"targets,omitempty"` // backends affected by signals
+	File     string        `json:"file,omitempty"`    // edited configuration file
+	Result   string        `json:"result"`            // result of the action
+	// targets have been omitted from the record sent to notification channel due to limited size of payload
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// auditTarget describes backend affected by the action.
+type auditTarget struct {
+	Pid       int    `json:"pid"`
+	Query     string `json:"query,omitempty"`
+	Signalled bool   `json:"signalled"` // signal has been sent successfully
+}
+
+// auditLog writes records about administrative actions. Nil audit log writes nothing.
+type auditLog struct {
+	filename string // append-only local file, empty if records are not written locally
+	postgres string // how records are sent to Postgres, empty if they are not sent
+}
+
+// newAuditLog creates audit log, nil is returned if audit is not required. Local file is checked it could be
+// opened for writing.
+func newAuditLog(filename string, postgres string) (*auditLog, error) {
+	if filename == "" && postgres == "" {
+		return nil, nil
+	}
+
+	if postgres != "" && postgres != AuditPostgresLog && postgres != AuditPostgresNotify {
+		return nil, fmt.Errorf("unknown audit target '%s'", postgres)
+	}
+
+	if filename != "" {
+		f, err := openAuditFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		_ = f.Close()
+	}
+
+	return &auditLog{filename: filename, postgres: postgres}, nil
+}
+
+// openAuditFile opens audit file for appending records.
+func openAuditFile(filename string) (*os.File, error) {
+	return os.OpenFile(filepath.Clean(filename), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+}
+
+// newAuditRecord creates record about action performed using the connection.
+func newAuditRecord(db *postgres.DB, action string, result string) auditRecord {
+	rec := auditRecord{Time: time.Now(), Action: action, Result: result}
+
+	if u, err := user.Current(); err == nil {
+		rec.OSUser = u.Username
+	}
+	if h, err := os.Hostname(); err == nil {
+		rec.Hostname = h
+	}
+
+	if db != nil && db.Config.Config != nil {
+		rec.DBHost = db.Config.Config.Host
+		rec.DBPort = db.Config.Config.Port
+		rec.DBUser = db.Config.Config.User
+		rec.Database = db.Config.Config.Database
+	}
+
+	return rec
+}
+
+// write writes the record into local file and sends it to Postgres, if required.
+func (a *auditLog) write(db *postgres.DB, rec auditRecord) error {
+	if a == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	if a.filename != "" {
+		f, err := openAuditFile(a.filename)
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(append(data, '\n'))
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+
+		err = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	switch a.postgres {
+	case AuditPostgresLog:
+		// DO blocks don't accept parameters, pass the record through custom setting.
+		_, err = db.Exec(query.ExecSetAuditRecord, string(data))
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(query.ExecRaiseAuditRecord)
+		return err
+	case AuditPostgresNotify:
+		payload, err := notifyPayload(rec)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(query.ExecNotifyAuditRecord, auditChannel, string(payload))
+		return err
+	}
+
+	return nil
+}
+
+// notifyPayload returns compact record which fits into notification payload. Texts of queries are omitted, targets
+// are omitted at all if there are too many of them. Full record is kept in the local file.
+func notifyPayload(rec auditRecord) ([]byte, error) {
+	targets := make([]auditTarget, len(rec.Targets))
+	for i, t := range rec.Targets {
+		targets[i] = auditTarget{Pid: t.Pid, Signalled: t.Signalled}
+	}
+	if len(targets) > 0 {
+		rec.Targets = targets
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) <= maxNotifyPayload {
+		return data, nil
+	}
+
+	rec.Targets, rec.Truncated = nil, true
+	return json.Marshal(rec)
+}
+
+// record writes record about the action and returns message with result of the action, complemented with error
+// occurred during writing the record.
+func (a *auditLog) record(db *postgres.DB, action string, targets []auditTarget, message string) string {
+	rec := newAuditRecord(db, action, message)
+	rec.Targets = targets
+
+	if err := a.write(db, rec); err != nil {
+		return fmt.Sprintf("%s (audit failed: %s)", message, err)
+	}
+
+	return message
+}
+
+// fileChecksum returns checksum of the file content, empty string is returned if file can't be read.
+func fileChecksum(filename string) string {
+	data, err := ioutil.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// editResult returns result of config file editing based on file checksums taken before and after editing.
+func editResult(before, after string) string {
+	switch {
+	case before == "" || after == "":
+		return "Edit: done, changes unknown (file is not readable)"
+	case before != after:
+		return "Edit: done, file modified"
+	default:
+		return "Edit: done, file not modified"
+	}
+}
@@ -0,0 +1,155 @@
+package top
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_newAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-audit-")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	testcases := []struct {
+		filename string
+		postgres string
+		want     *auditLog
+		valid    bool
+	}{
+		{valid: true},
+		{filename: filepath.Join(dir, "audit.log"), want: &auditLog{filename: filepath.Join(dir, "audit.log")}, valid: true},
+		{postgres: "log", want: &auditLog{postgres: "log"}, valid: true},
+		{postgres: "notify", want: &auditLog{postgres: "notify"}, valid: true},
+		{postgres: "invalid", valid: false},
+		{filename: filepath.Join(dir, "unknown", "audit.log"), valid: false},
+	}
+
+	for _, tc := range testcases {
+		got, err := newAuditLog(tc.filename, tc.postgres)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func Test_auditLog_record(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-audit-")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	filename := filepath.Join(dir, "audit.log")
+	a, err := newAuditLog(filename, "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Signals: done", a.record(nil, "terminate", []auditTarget{{Pid: 123, Query: "SELECT 1", Signalled: true}}, "Signals: done"))
+	assert.Equal(t, "Reload: successful", a.record(nil, "reload", nil, "Reload: successful"))
+
+	f, err := os.Open(filename)
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	var got []auditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec auditRecord
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		got = append(got, rec)
+	}
+
+	assert.Len(t, got, 2)
+	assert.Equal(t, "terminate", got[0].Action)
+	assert.Equal(t, []auditTarget{{Pid: 123, Query: "SELECT 1", Signalled: true}}, got[0].Targets)
+	assert.Equal(t, "Signals: done", got[0].Result)
+	assert.NotEqual(t, "", got[0].OSUser)
+	assert.False(t, got[0].Time.IsZero())
+	assert.Equal(t, "reload", got[1].Action)
+	assert.Nil(t, got[1].Targets)
+
+	// Nil audit log writes nothing.
+	var none *auditLog
+	assert.Equal(t, "Signals: done", none.record(nil, "cancel", nil, "Signals: done"))
+
+	// Failed writing is reported in message.
+	assert.NoError(t, os.RemoveAll(dir))
+	assert.Contains(t, a.record(nil, "cancel", nil, "Signals: done"), "Signals: done (audit failed:")
+}
+
+func Test_editResult(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-audit-")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	filename := filepath.Join(dir, "postgresql.conf")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("work_mem = '4MB'\n"), 0600))
+	before := fileChecksum(filename)
+	assert.Equal(t, "Edit: done, file not modified", editResult(before, fileChecksum(filename)))
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("work_mem = '8MB'\n"), 0600))
+	assert.Equal(t, "Edit: done, file modified", editResult(before, fileChecksum(filename)))
+
+	assert.Equal(t, "", fileChecksum(filepath.Join(dir, "unknown")))
+	assert.Equal(t, "Edit: done, changes unknown (file is not readable)", editResult(before, ""))
+}
+
+func Test_notifyPayload(t *testing.T) {
+	rec := auditRecord{Action: "terminate", Result: "Signals: done"}
+	rec.Targets = []auditTarget{{Pid: 123, Query: "SELECT 1", Signalled: true}, {Pid: 456, Query: "SELECT 2"}}
+
+	// Queries are omitted.
+	data, err := notifyPayload(rec)
+	assert.NoError(t, err)
+
+	var got auditRecord
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, []auditTarget{{Pid: 123, Signalled: true}, {Pid: 456}}, got.Targets)
+	assert.False(t, got.Truncated)
+	assert.Equal(t, "SELECT 1", rec.Targets[0].Query)
+
+	// Too many targets are omitted.
+	rec.Targets = nil
+	for i := 0; i < 1000; i++ {
+		rec.Targets = append(rec.Targets, auditTarget{Pid: 100000 + i, Query: strings.Repeat("x", 100), Signalled: true})
+	}
+
+	data, err = notifyPayload(rec)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(data), maxNotifyPayload)
+
+	got = auditRecord{}
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Nil(t, got.Targets)
+	assert.True(t, got.Truncated)
+	assert.Equal(t, "terminate", got.Action)
+	assert.Equal(t, "Signals: done", got.Result)
+}
+
+func Test_auditLog_write_postgres(t *testing.T) {
+	db, err := postgres.NewTestConnect()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+
+	for _, target := range []string{AuditPostgresLog, AuditPostgresNotify} {
+		a, err := newAuditLog("", target)
+		assert.NoError(t, err)
+		assert.NoError(t, a.write(db, newAuditRecord(db, "reload", "Reload: successful")))
+
+		// Record with long queries.
+		rec := newAuditRecord(db, "terminate", "Signals: done")
+		for i := 0; i < 100; i++ {
+			rec.Targets = append(rec.Targets, auditTarget{Pid: 100000 + i, Query: strings.Repeat("x", 1000), Signalled: true})
+		}
+		assert.NoError(t, a.write(db, rec))
+	}
+}
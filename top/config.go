@@ -4,18 +4,35 @@ import (
 	"github.com/lesovsky/pgcenter/internal/query"
 	"github.com/lesovsky/pgcenter/internal/stat"
 	"github.com/lesovsky/pgcenter/internal/view"
+	"time"
 )
 
 // config defines 'top' program runtime configuration.
 type config struct {
-	view         view.View      // Current active view.
-	views        view.Views     // List of all available views.
-	queryOptions query.Options  // Queries' settings that might depend on Postgres version.
-	viewCh       chan view.View // Channel used for passing view settings to stats goroutine.
-	logtail      stat.Logfile   // Logfile used for working with Postgres log file.
-	dialog       dialogType     // Remember current user-started dialog, used for selecting needed dialog handler.
-	menu         menuStyle      // When working with menus, keep properties of the menu.
-	procMask     int            // Process mask used for selecting group of process.
+	view         view.View          // Current active view.
+	views        view.Views         // List of all available views.
+	queryOptions query.Options      // Queries' settings that might depend on Postgres version.
+	viewCh       chan view.View     // Channel used for passing view settings to stats goroutine.
+	refreshCh    chan time.Duration // Channel used for passing global refresh interval to stats goroutine.
+	logtail      stat.Logfile       // Logfile used for working with Postgres log file.
+	dialog       dialogType         // Remember current user-started dialog, used for selecting needed dialog handler.
+	menu         menuStyle          // When working with menus, keep properties of the menu.
+	procMask     int                // Process mask used for selecting group of process.
+	cursor       cursor             // Position of the selected row in the stats view.
+	lastStat     stat.Stat          // Recently received stats, used for redrawing the screen without waiting for next refresh.
+	refresh      time.Duration      // Stats refresh interval.
+	profile      string             // Name of the settings profile used for saving and restoring settings.
+	settingsFile string             // File where settings profiles are saved.
+	history      history            // Recent stats snapshots.
+	readOnly     bool               // Read-only mode enabled by command line option, it is not saved into profile.
+	readOnlySet  bool               // Read-only mode enabled by settings profile, it is saved into profile.
+	audit        *auditLog          // Audit log of actions which change state of Postgres, nil if audit is disabled.
+}
+
+// isReadOnly returns true if actions which change state of Postgres are disabled by command line option or by
+// settings profile.
+func (c *config) isReadOnly() bool {
+	return c.readOnly || c.readOnlySet
 }
 
 // newConfig creates 'top' initial configuration.
@@ -23,7 +40,11 @@ func newConfig() *config {
 	views := view.New()
 
 	return &config{
-		views:  views,
-		viewCh: make(chan view.View),
+		views:     views,
+		viewCh:    make(chan view.View),
+		refreshCh: make(chan time.Duration),
+		refresh:   time.Second,
+		profile:   defaultProfile,
+		history:   newHistory(defaultHistorySize),
 	}
 }
@@ -149,6 +149,8 @@ func switchViewTo(app *app, c string) func(g *gocui.Gui, _ *gocui.View) error {
 func viewSwitchHandler(config *config, c string) {
 	config.views[config.view.Name] = config.view
 	config.view = config.views[c]
+	config.cursor = cursor{}
+	config.history.reset()
 	config.viewCh <- config.view
 }
 
@@ -294,11 +296,9 @@ func changeRefresh(answer string, config *config) string {
 		return "Refresh: input value should be between 1 and 300"
 	}
 
-	// Set refresh interval, send it to stats channel and reset interval in the view.
-	// Refresh interval should not be saved as a per-view setting. It's used as a setting for stats goroutine.
-	config.view.Refresh = time.Duration(interval) * time.Second
-	config.viewCh <- config.view
-	config.view.Refresh = 0
+	// Set global refresh interval and send it to stats goroutine. Views with own refresh interval keep using it.
+	config.refresh = time.Duration(interval) * time.Second
+	config.refreshCh <- config.refresh
 
 	return "Refresh: ok"
 }
@@ -430,14 +430,16 @@ func Test_changeRefresh(t *testing.T) {
 
 		wg.Add(1)
 		go func() {
-			v := <-config.viewCh
-			assert.Equal(t, v.Refresh, 5*time.Second)
+			interval := <-config.refreshCh
+			assert.Equal(t, 5*time.Second, interval)
 			wg.Done()
 		}()
 
 		assert.Equal(t, "Refresh: ok", changeRefresh("5", config))
 		wg.Wait()
-		close(config.viewCh)
+		assert.Equal(t, 5*time.Second, config.refresh)
+		assert.Equal(t, time.Duration(0), config.view.Refresh) // view is not changed
+		close(config.refreshCh)
 	})
 
 	// test invalid input
@@ -0,0 +1,243 @@
+package top
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"strings"
+)
+
+// cursor describes position of the highlighted (selected) row and visible part of stats in 'dbstat' view.
+type cursor struct {
+	row       int                // index of the selected row among printed rows
+	offset    int                // index of the first row visible on the screen
+	limit     int                // number of rows fit on the screen
+	key       string             // value of view's unique key in the selected row, used for tracking the row between refreshes
+	rows      [][]sql.NullString // rows printed at last refresh
+	colOffset int                // number of columns scrolled to the left (the first column is frozen and always visible)
+	cols      []int              // indexes of columns printed at last refresh
+	ncols     int                // total number of columns
+}
+
+// sync places cursor on the previously selected row (rows might be reordered between refreshes) and adjusts
+// scrolling offset in the way the selected row remains visible on the screen.
+func (c *cursor) sync(rows [][]sql.NullString, ukey int, limit int) {
+	c.rows = rows
+	c.limit = limit
+
+	if c.key != "" {
+		for i, row := range rows {
+			if ukey < len(row) && row[ukey].String == c.key {
+				c.row = i
+				break
+			}
+		}
+	}
+
+	c.adjust(ukey)
+}
+
+// move moves cursor to requested direction.
+func (c *cursor) move(d direction, ukey int) {
+	page := c.limit
+	if page < 1 {
+		page = 1
+	}
+
+	switch d {
+	case moveUp:
+		c.row--
+	case moveDown:
+		c.row++
+	case movePageUp:
+		c.row -= page
+	case movePageDown:
+		c.row += page
+	case moveHome:
+		c.row = 0
+	case moveEnd:
+		c.row = len(c.rows) - 1
+	}
+
+	c.adjust(ukey)
+}
+
+// adjust clamps cursor position by number of rows, updates scrolling offset and remembers key of the selected row.
+func (c *cursor) adjust(ukey int) {
+	if c.row >= len(c.rows) {
+		c.row = len(c.rows) - 1
+	}
+	if c.row < 0 {
+		c.row = 0
+	}
+
+	if c.row < c.offset {
+		c.offset = c.row
+	}
+	if c.limit > 0 && c.row >= c.offset+c.limit {
+		c.offset = c.row - c.limit + 1
+	}
+	if c.offset < 0 {
+		c.offset = 0
+	}
+
+	c.key = ""
+	if c.row < len(c.rows) && ukey < len(c.rows[c.row]) {
+		c.key = c.rows[c.row][ukey].String
+	}
+}
+
+// pan scrolls columns to the left or to the right, the first column is never scrolled.
+func (c *cursor) pan(d direction) {
+	switch d {
+	case moveLeft:
+		if c.colOffset > 0 {
+			c.colOffset--
+		}
+	case moveRight:
+		// Don't scroll further when the last column is already visible.
+		if len(c.cols) > 0 && c.cols[len(c.cols)-1] < c.ncols-1 {
+			c.colOffset++
+		}
+	}
+}
+
+// visibleColumns returns indexes of columns which fit into the screen of specified width. The first column is always
+// visible, the rest of columns are shifted by scrolling offset. Zero or negative width means no limits.
+func (c *cursor) visibleColumns(widths map[int]int, ncols int, width int) []int {
+	c.ncols = ncols
+
+	// Clamp offset, at least one scrollable column should remain visible.
+	if c.colOffset > ncols-2 {
+		c.colOffset = ncols - 2
+	}
+	if c.colOffset < 0 {
+		c.colOffset = 0
+	}
+
+	if ncols == 0 {
+		c.cols = nil
+		return nil
+	}
+
+	cols := []int{0}
+	total := widths[0] + 2
+	for i := 1 + c.colOffset; i < ncols; i++ {
+		// Partially visible column is also included, the rest of columns are not visible.
+		if width > 0 && total >= width {
+			break
+		}
+		cols = append(cols, i)
+		total += widths[i] + 2
+	}
+
+	c.cols = cols
+	return cols
+}
+
+// position returns description of the visible part of stats.
+func (c *cursor) position() string {
+	if len(c.rows) == 0 {
+		return "No rows"
+	}
+
+	last := len(c.rows)
+	if c.limit > 0 && c.offset+c.limit < last {
+		last = c.offset + c.limit
+	}
+
+	msg := fmt.Sprintf("Row %d of %d (shown %d-%d)", c.row+1, len(c.rows), c.offset+1, last)
+	if len(c.cols) > 1 {
+		msg = fmt.Sprintf("%s, columns %d-%d of %d", msg, c.cols[1]+1, c.cols[len(c.cols)-1]+1, c.ncols)
+	}
+
+	return msg
+}
+
+// value returns value of specified column in the selected row.
+func (c *cursor) value(col int) string {
+	if c.row >= len(c.rows) || col < 0 || col >= len(c.rows[c.row]) {
+		return ""
+	}
+
+	return c.rows[c.row][col].String
+}
+
+// moveRowCursor handles user input and moves cursor over rows in 'dbstat' view.
+func moveRowCursor(d direction, config *config) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		config.cursor.move(d, config.view.UniqueKey)
+		return redrawDbstat(g, config)
+	}
+}
+
+// panColumns handles user input and scrolls columns in 'dbstat' view.
+func panColumns(d direction, config *config) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		config.cursor.pan(d)
+		return redrawDbstat(g, config)
+	}
+}
+
+// redrawDbstat redraws stats using latest received data, don't wait for next refresh. Position of the visible part
+// of stats is shown in cmdline.
+func redrawDbstat(g *gocui.Gui, config *config) error {
+	v, err := g.View("dbstat")
+	if err != nil {
+		return fmt.Errorf("set focus on dbstat view failed: %s", err)
+	}
+	v.Clear()
+
+	err = printDbstat(v, config, config.lastStat, config.cursor.limit)
+	if err != nil {
+		return err
+	}
+
+	printCmdline(g, config.cursor.position())
+	return nil
+}
+
+// selectedAnswer returns value taken from the selected row, which is suitable as an answer for the dialog.
+func selectedAnswer(config *config, d dialogType) string {
+	switch d {
+	case dialogCancelQuery, dialogTerminateBackend:
+		// In activity and locks views, backend's pid is the first column. In locks view pids are indented.
+		if config.view.Name == "activity" || config.view.Name == "locks" {
+			return strings.TrimSpace(config.cursor.value(0))
+		}
+	case dialogQueryReport:
+		// In statements views, queryid is used as unique key.
+		if strings.HasPrefix(config.view.Name, "statements") {
+			return config.cursor.value(config.view.UniqueKey)
+		}
+	}
+
+	return ""
+}
+
+// showDetails shows details about object in the selected row.
+func showDetails(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		var message string
+
+		switch {
+		case strings.HasPrefix(app.config.view.Name, "statements"):
+			var r report
+			r, message = getQueryReport(selectedAnswer(app.config, dialogQueryReport), app.postgresProps.VersionNum, app.db)
+			if message == "" {
+				message = printQueryReport(g, r, app.uiExit)
+			}
+		case app.config.view.Name == "tables":
+			var r tableReport
+			r, message = getTableReport(app.config.cursor.value(0), app.db)
+			if message == "" {
+				message = printTableReport(g, r, app.uiExit)
+			}
+		default:
+			message = "Details: not available in this view"
+		}
+
+		printCmdline(g, message)
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package top
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestCursorRows(keys ...string) [][]sql.NullString {
+	rows := make([][]sql.NullString, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []sql.NullString{{String: k, Valid: true}, {String: "value", Valid: true}})
+	}
+	return rows
+}
+
+func Test_cursor_sync(t *testing.T) {
+	c := cursor{}
+
+	// Cursor is placed on the first row by default.
+	c.sync(newTestCursorRows("a", "b", "c", "d"), 0, 2)
+	assert.Equal(t, 0, c.row)
+	assert.Equal(t, "a", c.key)
+
+	// Cursor follows the selected row when rows are reordered.
+	c.move(moveDown, 0)
+	assert.Equal(t, "b", c.key)
+	c.sync(newTestCursorRows("d", "c", "b", "a"), 0, 2)
+	assert.Equal(t, 2, c.row)
+	assert.Equal(t, 1, c.offset)
+	assert.Equal(t, "b", c.key)
+
+	// Cursor stays in rows range when selected row has gone.
+	c.sync(newTestCursorRows("x"), 0, 2)
+	assert.Equal(t, 0, c.row)
+	assert.Equal(t, 0, c.offset)
+	assert.Equal(t, "x", c.key)
+
+	// No rows.
+	c.sync(nil, 0, 2)
+	assert.Equal(t, 0, c.row)
+	assert.Equal(t, "", c.key)
+	assert.Equal(t, "", c.value(0))
+}
+
+func Test_cursor_move(t *testing.T) {
+	testcases := []struct {
+		d          direction
+		wantRow    int
+		wantOffset int
+	}{
+		{d: moveDown, wantRow: 1, wantOffset: 0},
+		{d: movePageDown, wantRow: 4, wantOffset: 2},
+		{d: moveEnd, wantRow: 9, wantOffset: 7},
+		{d: moveDown, wantRow: 9, wantOffset: 7},
+		{d: movePageUp, wantRow: 6, wantOffset: 6},
+		{d: moveUp, wantRow: 5, wantOffset: 5},
+		{d: moveHome, wantRow: 0, wantOffset: 0},
+		{d: moveUp, wantRow: 0, wantOffset: 0},
+	}
+
+	c := cursor{}
+	c.sync(newTestCursorRows("0", "1", "2", "3", "4", "5", "6", "7", "8", "9"), 0, 3)
+
+	for _, tc := range testcases {
+		c.move(tc.d, 0)
+		assert.Equal(t, tc.wantRow, c.row)
+		assert.Equal(t, tc.wantOffset, c.offset)
+		assert.Equal(t, "value", c.value(1))
+	}
+}
+
+func Test_selectedAnswer(t *testing.T) {
+	testcases := []struct {
+		view string
+		d    dialogType
+		want string
+	}{
+		{view: "activity", d: dialogCancelQuery, want: "123"},
+		{view: "locks", d: dialogTerminateBackend, want: "123"},
+		{view: "statements_timings", d: dialogQueryReport, want: "abcdef"},
+		{view: "tables", d: dialogCancelQuery, want: ""},
+		{view: "activity", d: dialogFilter, want: ""},
+	}
+
+	for _, tc := range testcases {
+		config := newConfig()
+		config.view = config.views[tc.view]
+		config.view.UniqueKey = 1
+		config.cursor.sync([][]sql.NullString{{{String: "  123", Valid: true}, {String: "abcdef", Valid: true}}}, 1, 10)
+
+		assert.Equal(t, tc.want, selectedAnswer(config, tc.d))
+	}
+}
+
+func Test_cursor_pan(t *testing.T) {
+	c := cursor{}
+	widths := map[int]int{0: 8, 1: 8, 2: 8, 3: 8, 4: 8}
+
+	// Screen fits three columns: frozen first and two scrollable.
+	assert.Equal(t, []int{0, 1, 2}, c.visibleColumns(widths, 5, 30))
+
+	c.pan(moveLeft)
+	assert.Equal(t, 0, c.colOffset)
+
+	c.pan(moveRight)
+	assert.Equal(t, []int{0, 2, 3}, c.visibleColumns(widths, 5, 30))
+
+	c.pan(moveRight)
+	assert.Equal(t, []int{0, 3, 4}, c.visibleColumns(widths, 5, 30))
+
+	// The last column is visible, don't scroll further.
+	c.pan(moveRight)
+	assert.Equal(t, 2, c.colOffset)
+
+	c.sync(newTestCursorRows("a", "b"), 0, 10)
+	assert.Equal(t, "Row 1 of 2 (shown 1-2), columns 4-5 of 5", c.position())
+
+	// No limits.
+	c.pan(moveLeft)
+	c.pan(moveLeft)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, c.visibleColumns(widths, 5, 0))
+
+	// Offset is clamped when number of columns is reduced.
+	c.colOffset = 10
+	assert.Equal(t, []int{0, 1}, c.visibleColumns(widths, 2, 30))
+	assert.Equal(t, []int(nil), c.visibleColumns(widths, 0, 30))
+}
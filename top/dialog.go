@@ -22,6 +22,7 @@ const (
 	dialogChangeAge
 	dialogQueryReport
 	dialogChangeRefresh
+	dialogReplaySeek
 )
 
 // dialogPrompts returns dialog prompt depending on user-requested actions.
@@ -37,6 +38,7 @@ func dialogPrompts(t dialogType) string {
 		dialogChangeAge:        "Enter new min age, format: HH:MM:SS[.NN]: ",
 		dialogQueryReport:      "Enter the queryid: ",
 		dialogChangeRefresh:    "Change refresh (min 1, max 300) to ",
+		dialogReplaySeek:       "Seek to time, format: [YYYY-MM-DD ]HH:MM:SS: ",
 	}
 
 	return prompts[t]
@@ -47,11 +49,16 @@ func dialogOpen(app *app, d dialogType) func(g *gocui.Gui, _ *gocui.View) error
 	return func(g *gocui.Gui, _ *gocui.View) error {
 		prompt := dialogPrompts(d)
 
+		// Signals to single backends are also allowed in locks view, e.g. for terminating root blockers.
+		signalLocks := (d == dialogCancelQuery || d == dialogTerminateBackend) && app.config.view.Name == "locks"
+
 		// some types of actions allowed only in specifics stats contexts.
-		if (d > dialogFilter && d <= dialogChangeAge) && app.config.view.Name != "activity" {
+		if (d > dialogFilter && d <= dialogChangeAge) && app.config.view.Name != "activity" && !signalLocks {
 			var msg string
 			switch d {
-			case dialogCancelQuery, dialogTerminateBackend, dialogCancelGroup, dialogTerminateGroup:
+			case dialogCancelQuery, dialogTerminateBackend:
+				msg = "Terminate backends or cancel queries allowed in pg_stat_activity and locks views only."
+			case dialogCancelGroup, dialogTerminateGroup:
 				msg = "Terminate backends or cancel queries allowed in pg_stat_activity view only."
 			case dialogSetMask:
 				msg = "State mask setup allowed in pg_stat_activity view only."
@@ -92,6 +99,18 @@ func dialogOpen(app *app, d dialogType) func(g *gocui.Gui, _ *gocui.View) error
 		v.Editable = true
 		v.Frame = false
 
+		// Suggest the value taken from the selected row, user can edit it or accept as-is.
+		if answer := selectedAnswer(app.config, d); answer != "" {
+			_, err = fmt.Fprint(v, answer)
+			if err != nil {
+				return fmt.Errorf("print to dialog view failed: %s", err)
+			}
+			err = v.SetCursor(len(answer), 0)
+			if err != nil {
+				return fmt.Errorf("set cursor in dialog view failed: %s", err)
+			}
+		}
+
 		if _, err := g.SetCurrentView("dialog"); err != nil {
 			return fmt.Errorf("set dialog view as current on layout failed: %s", err)
 		}
@@ -112,17 +131,23 @@ func dialogFinish(app *app) func(g *gocui.Gui, v *gocui.View) error {
 		answer := strings.TrimPrefix(v.Buffer(), dialogPrompts(app.config.dialog))
 		answer = strings.TrimSuffix(answer, "\n")
 
+		// Dialogs which change state of Postgres are not finished in read-only mode.
+		if app.config.isReadOnly() && isMutatingDialog(app.config.dialog) {
+			printCmdline(g, readOnlyMessage)
+			return dialogClose(g, v)
+		}
+
 		var message string
 
 		switch app.config.dialog {
 		case dialogPgReload:
-			message = doReload(answer, app.db)
+			message = doReload(answer, app.db, app.config.audit)
 		case dialogFilter:
 			message = setFilter(answer, app.config.view)
 		case dialogCancelQuery:
-			message = killSingle(app.db, "cancel", answer)
+			message = killSingle(app.db, "cancel", answer, app.config.audit)
 		case dialogTerminateBackend:
-			message = killSingle(app.db, "terminate", answer)
+			message = killSingle(app.db, "terminate", answer, app.config.audit)
 		case dialogSetMask:
 			message = setProcMask(answer, app.config)
 		case dialogCancelGroup:
@@ -139,6 +164,8 @@ func dialogFinish(app *app) func(g *gocui.Gui, v *gocui.View) error {
 			}
 		case dialogChangeRefresh:
 			message = changeRefresh(answer, app.config)
+		case dialogReplaySeek:
+			message = seekReplay(answer, app.replay)
 		case dialogNone:
 			// do nothing
 		}
@@ -149,6 +176,16 @@ func dialogFinish(app *app) func(g *gocui.Gui, v *gocui.View) error {
 	}
 }
 
+// isMutatingDialog returns true if the dialog leads to action which changes state of Postgres.
+func isMutatingDialog(d dialogType) bool {
+	switch d {
+	case dialogPgReload, dialogCancelQuery, dialogTerminateBackend, dialogCancelGroup, dialogTerminateGroup:
+		return true
+	default:
+		return false
+	}
+}
+
 // dialogCancel reset dialog state when user cancels input.
 func dialogCancel(app *app) func(g *gocui.Gui, v *gocui.View) error {
 	return func(g *gocui.Gui, v *gocui.View) error {
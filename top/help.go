@@ -13,26 +13,41 @@ general actions:
     s,t,i             's' tables sizes, 't' tables, 'i' indexes.
     x,X               'x' pg_stat_statements switch, 'X' pg_stat_statements menu.
     p,P               'p' pg_stat_progress_* switch, 'P' pg_stat_progress_* menu.
+    w                 'w' locks tree (blocking chains).
+    V                 'V' user-defined views menu.
     Left,Right,<,/    'Left,Right' change column sort, '<' desc/asc sort toggle, '/' set filter.
-    Up,Down           'Up' increase column width, 'Down' decrease column width.
+    ],[               ']' increase column width, '[' decrease column width.
+    Up,Down,PgUp,PgDn select row: 'Up,Down' move by one row, 'PgUp,PgDn' move by page,
+    Home,End,Enter    'Home,End' first/last row, 'Enter' show details of selected row.
+    {,}               scroll columns: '{' scroll left, '}' scroll right (the first column is frozen).
     C,E,R       config: 'C' show config, 'E' edit configs, 'R' reload config.
     ~                 start psql session.
     l                 open log file with pager.
 
+history actions:
+    Space,(,)   'Space' pause/resume display, '(' step back, ')' step forward through recent snapshots.
+
+replay actions (when started with --from-file):
+    Space,(,)   'Space' play/pause, '(' previous snapshot, ')' next snapshot.
+    +,-,g       '+' faster playback, '-' slower playback, 'g' seek to time.
+
 extra stats actions:
     B,N,L       'B' diskstat, 'N' nicstat, 'L' logtail.
 
 activity actions:
-    -,_         '-' cancel backend by pid, '_' terminate backend by pid.
+    -,_         '-' cancel backend by pid, '_' terminate backend by pid (also in locks tree),
+                pid of the selected row is suggested by default.
     n,m         'n' set new mask, 'm' show current mask.
     k,K         'k' cancel group of queries using mask, 'K' terminate group of backends using mask.
     I           show IDLE connections toggle.
     A           change activity age threshold.
-    G           get query report.
+    G           get query report (queryid of the selected row is suggested by default).
 
 other actions:
     , Q         ',' show system tables on/off, 'Q' reset postgresql statistics counters.
     z           'z' set refresh interval.
+    W           save current settings (views order, widths, filters, refresh, etc.) into profile.
+                In read-only mode (--read-only) 'Q', 'E', 'R', '~', '-', '_', 'k' and 'K' are disabled.
     h,F1        show this tab.
     q,Ctrl+Q    quit.
 
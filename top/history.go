@@ -0,0 +1,144 @@
+package top
+
+import (
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"time"
+)
+
+const (
+	// defaultHistorySize defines default number of stats snapshots kept in history.
+	defaultHistorySize = 300
+)
+
+// snapshot defines stats received at particular moment.
+type snapshot struct {
+	ts   time.Time // time when stats have been received
+	stat stat.Stat // received stats
+}
+
+// history keeps recent stats snapshots and allows to step through them when display is paused.
+type history struct {
+	size   int        // max number of kept snapshots
+	items  []snapshot // snapshots ordered from the oldest to the newest
+	paused bool       // display is paused, new snapshots are kept but not shown
+	pos    int        // index of the shown snapshot when display is paused
+}
+
+// newHistory creates history of specified size.
+func newHistory(size int) history {
+	if size < 1 {
+		size = 1
+	}
+	return history{size: size}
+}
+
+// add adds snapshot to history, the oldest snapshot is removed when history is full.
+func (h *history) add(s stat.Stat, ts time.Time) {
+	h.items = append(h.items, snapshot{ts: ts, stat: s})
+
+	if len(h.items) > h.size {
+		h.items = h.items[len(h.items)-h.size:]
+
+		// Keep pointing to the same snapshot, if it has been removed point to the oldest one.
+		if h.pos > 0 {
+			h.pos--
+		}
+	}
+}
+
+// reset removes all snapshots and resumes display.
+func (h *history) reset() {
+	h.items = nil
+	h.paused = false
+	h.pos = 0
+}
+
+// togglePause pauses or resumes display. When paused, the newest snapshot is shown.
+func (h *history) togglePause() {
+	h.paused = !h.paused
+	h.pos = len(h.items) - 1
+}
+
+// step moves position to older (negative) or newer (positive) snapshot, display is paused when stepping.
+// Returns false if there is no snapshots in requested direction.
+func (h *history) step(n int) bool {
+	if !h.paused {
+		h.paused = true
+		h.pos = len(h.items) - 1
+	}
+
+	pos := h.pos + n
+	if pos < 0 || pos >= len(h.items) {
+		return false
+	}
+
+	h.pos = pos
+	return true
+}
+
+// current returns the shown snapshot.
+func (h *history) current() (snapshot, bool) {
+	if len(h.items) == 0 {
+		return snapshot{}, false
+	}
+
+	if !h.paused {
+		return h.items[len(h.items)-1], true
+	}
+
+	return h.items[h.pos], true
+}
+
+// status returns description of the shown snapshot.
+func (h *history) status() string {
+	s, ok := h.current()
+	if !ok {
+		return "History: no snapshots"
+	}
+
+	if !h.paused {
+		return "History: resumed"
+	}
+
+	return fmt.Sprintf("History: paused, snapshot %d of %d (%s)", h.pos+1, len(h.items), s.ts.Format("15:04:05"))
+}
+
+// togglePauseHistory pauses or resumes display of stats.
+func togglePauseHistory(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		app.config.history.togglePause()
+		return showHistory(g, app)
+	}
+}
+
+// stepHistory shows older or newer stats snapshot.
+func stepHistory(app *app, n int) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		if !app.config.history.step(n) {
+			if n < 0 {
+				printCmdline(g, "History: no older snapshots")
+			} else {
+				printCmdline(g, "History: no newer snapshots")
+			}
+			return nil
+		}
+
+		return showHistory(g, app)
+	}
+}
+
+// showHistory prints snapshot selected in history and its description.
+func showHistory(g *gocui.Gui, app *app) error {
+	s, ok := app.config.history.current()
+	if ok {
+		err := drawStat(g, app, s.stat, s.ts)
+		if err != nil {
+			return err
+		}
+	}
+
+	printCmdline(g, app.config.history.status())
+	return nil
+}
@@ -0,0 +1,56 @@
+package top
+
+import (
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_history(t *testing.T) {
+	h := newHistory(3)
+
+	_, ok := h.current()
+	assert.False(t, ok)
+	assert.Equal(t, "History: no snapshots", h.status())
+
+	ts := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		h.add(stat.Stat{Pgstat: stat.Pgstat{Activity: stat.Activity{ConnTotal: i}}}, ts.Add(time.Duration(i)*time.Second))
+	}
+
+	// Only the last 3 snapshots are kept.
+	assert.Len(t, h.items, 3)
+	s, ok := h.current()
+	assert.True(t, ok)
+	assert.Equal(t, 4, s.stat.Activity.ConnTotal)
+
+	// Step back pauses display.
+	assert.True(t, h.step(-1))
+	assert.True(t, h.paused)
+	s, _ = h.current()
+	assert.Equal(t, 3, s.stat.Activity.ConnTotal)
+	assert.Equal(t, "History: paused, snapshot 2 of 3 (10:00:03)", h.status())
+
+	assert.True(t, h.step(-1))
+	assert.False(t, h.step(-1))
+
+	// New snapshots don't change the shown snapshot.
+	h.add(stat.Stat{Pgstat: stat.Pgstat{Activity: stat.Activity{ConnTotal: 5}}}, ts.Add(5*time.Second))
+	s, _ = h.current()
+	assert.Equal(t, 3, s.stat.Activity.ConnTotal)
+
+	assert.True(t, h.step(1))
+	assert.True(t, h.step(1))
+	assert.False(t, h.step(1))
+
+	// Resume shows the newest snapshot.
+	h.togglePause()
+	assert.False(t, h.paused)
+	s, _ = h.current()
+	assert.Equal(t, 5, s.stat.Activity.ConnTotal)
+	assert.Equal(t, "History: resumed", h.status())
+
+	h.reset()
+	assert.Len(t, h.items, 0)
+}
@@ -21,11 +21,17 @@ func keybindings(app *app) error {
 		{"sysstat", 'q', app.quit()},
 		{"sysstat", gocui.KeyArrowLeft, orderKeyLeft(app.config)},
 		{"sysstat", gocui.KeyArrowRight, orderKeyRight(app.config)},
-		{"sysstat", gocui.KeyArrowUp, increaseWidth(app.config)},
-		{"sysstat", gocui.KeyArrowDown, decreaseWidth(app.config)},
+		{"sysstat", ']', increaseWidth(app.config)},
+		{"sysstat", '[', decreaseWidth(app.config)},
+		{"sysstat", gocui.KeyArrowUp, moveRowCursor(moveUp, app.config)},
+		{"sysstat", gocui.KeyArrowDown, moveRowCursor(moveDown, app.config)},
+		{"sysstat", gocui.KeyPgup, moveRowCursor(movePageUp, app.config)},
+		{"sysstat", gocui.KeyPgdn, moveRowCursor(movePageDown, app.config)},
+		{"sysstat", gocui.KeyHome, moveRowCursor(moveHome, app.config)},
+		{"sysstat", gocui.KeyEnd, moveRowCursor(moveEnd, app.config)},
+		{"sysstat", '{', panColumns(moveLeft, app.config)},
+		{"sysstat", '}', panColumns(moveRight, app.config)},
 		{"sysstat", '<', switchSortOrder(app.config)},
-		{"sysstat", ',', toggleSysTables(app.config)},
-		{"sysstat", 'I', toggleIdleConns(app.config)},
 		{"sysstat", 'd', switchViewTo(app, "databases")},
 		{"sysstat", 'r', switchViewTo(app, "replication")},
 		{"sysstat", 't', switchViewTo(app, "tables")},
@@ -35,18 +41,58 @@ func keybindings(app *app) error {
 		{"sysstat", 'p', switchViewTo(app, "progress")},
 		{"sysstat", 'a', switchViewTo(app, "activity")},
 		{"sysstat", 'x', switchViewTo(app, "statements")},
-		{"sysstat", 'Q', resetStat(app.db, app.postgresProps.ExtPGSSAvail)},
-		{"sysstat", 'E', menuOpen(menuConf, app.config, false)},
+		{"sysstat", 'w', switchViewTo(app, "locks")},
 		{"sysstat", 'X', menuOpen(menuPgss, app.config, app.postgresProps.ExtPGSSAvail)},
 		{"sysstat", 'P', menuOpen(menuProgress, app.config, false)},
+		{"sysstat", 'V', menuOpen(menuCustom, app.config, false)},
+		{"sysstat", '/', dialogOpen(app, dialogFilter)},
+		{"dialog", gocui.KeyEsc, dialogCancel(app)},
+		{"dialog", gocui.KeyEnter, dialogFinish(app)},
+		{"menu", gocui.KeyEsc, menuClose},
+		{"menu", gocui.KeyArrowUp, moveCursor(moveUp, app.config)},
+		{"menu", gocui.KeyArrowDown, moveCursor(moveDown, app.config)},
+		{"menu", gocui.KeyEnter, menuSelect(app)},
+		{"sysstat", 'h', showHelp},
+		{"sysstat", gocui.KeyF1, showHelp},
+		{"help", gocui.KeyEsc, closeHelp},
+		{"help", 'q', closeHelp},
+	}
+
+	if app.replay != nil {
+		keys = append(keys, replayKeys(app)...)
+	} else {
+		keys = append(keys, liveKeys(app)...)
+		if app.config.isReadOnly() {
+			keys = readOnlyKeys(keys)
+		}
+	}
+
+	app.ui.InputEsc = true
+
+	for _, k := range keys {
+		if err := app.ui.SetKeybinding(k.viewname, k.key, gocui.ModNone, k.handler); err != nil {
+			return fmt.Errorf("setup keybindings failed: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// liveKeys returns key bindings used when stats are read from Postgres.
+func liveKeys(app *app) []key {
+	return []key{
+		{"sysstat", gocui.KeyEnter, showDetails(app)},
+		{"sysstat", ',', toggleSysTables(app.config)},
+		{"sysstat", 'I', toggleIdleConns(app.config)},
+		{"sysstat", 'Q', resetStat(app.db, app.postgresProps.ExtPGSSAvail, app.config)},
+		{"sysstat", 'E', menuOpen(menuConf, app.config, false)},
 		{"sysstat", 'l', showPgLog(app.db, app.postgresProps.VersionNum, app.uiExit)},
 		{"sysstat", 'C', showPgConfig(app.db, app.uiExit)},
-		{"sysstat", '~', runPsql(app.db, app.uiExit)},
+		{"sysstat", '~', runPsql(app.db, app.uiExit, app.config)},
 		{"sysstat", 'B', showExtra(app, stat.CollectDiskstats)},
 		{"sysstat", 'N', showExtra(app, stat.CollectNetdev)},
 		{"sysstat", 'L', showExtra(app, stat.CollectLogtail)},
 		{"sysstat", 'R', dialogOpen(app, dialogPgReload)},
-		{"sysstat", '/', dialogOpen(app, dialogFilter)},
 		{"sysstat", '-', dialogOpen(app, dialogCancelQuery)},
 		{"sysstat", '_', dialogOpen(app, dialogTerminateBackend)},
 		{"sysstat", 'n', dialogOpen(app, dialogSetMask)},
@@ -56,25 +102,58 @@ func keybindings(app *app) error {
 		{"sysstat", 'A', dialogOpen(app, dialogChangeAge)},
 		{"sysstat", 'G', dialogOpen(app, dialogQueryReport)},
 		{"sysstat", 'z', dialogOpen(app, dialogChangeRefresh)},
-		{"dialog", gocui.KeyEsc, dialogCancel(app)},
-		{"dialog", gocui.KeyEnter, dialogFinish(app)},
-		{"menu", gocui.KeyEsc, menuClose},
-		{"menu", gocui.KeyArrowUp, moveCursor(moveUp, app.config)},
-		{"menu", gocui.KeyArrowDown, moveCursor(moveDown, app.config)},
-		{"menu", gocui.KeyEnter, menuSelect(app)},
-		{"sysstat", 'h', showHelp},
-		{"sysstat", gocui.KeyF1, showHelp},
-		{"help", gocui.KeyEsc, closeHelp},
-		{"help", 'q', closeHelp},
+		{"sysstat", 'W', saveSettings(app.config)},
+		{"sysstat", gocui.KeySpace, togglePauseHistory(app)},
+		{"sysstat", '(', stepHistory(app, -1)},
+		{"sysstat", ')', stepHistory(app, 1)},
 	}
+}
 
-	app.ui.InputEsc = true
+// readOnlyActions defines keys of actions which change state of Postgres: stats reset, editing configuration,
+// psql session, reload and signals to backends.
+var readOnlyActions = map[interface{}]bool{
+	'Q': true, 'E': true, '~': true, 'R': true, '-': true, '_': true, 'k': true, 'K': true,
+}
 
+// readOnlyKeys returns key bindings where actions which change state of Postgres are replaced with notice.
+func readOnlyKeys(keys []key) []key {
+	result := make([]key, 0, len(keys))
 	for _, k := range keys {
-		if err := app.ui.SetKeybinding(k.viewname, k.key, gocui.ModNone, k.handler); err != nil {
-			return fmt.Errorf("setup keybindings failed: %s", err)
+		if k.viewname == "sysstat" && readOnlyActions[k.key] {
+			k.handler = readOnlyNotAvailable
 		}
+		result = append(result, k)
 	}
 
+	return result
+}
+
+// readOnlyMessage defines notice shown when action is not available in read-only mode.
+const readOnlyMessage = "Not available in read-only mode."
+
+// readOnlyNotAvailable notifies user the action is not available in read-only mode.
+func readOnlyNotAvailable(g *gocui.Gui, _ *gocui.View) error {
+	printCmdline(g, readOnlyMessage)
 	return nil
 }
+
+// replayKeys returns key bindings used when recorded stats are replayed. Actions which require connection to
+// Postgres are not available.
+func replayKeys(app *app) []key {
+	keys := []key{
+		{"sysstat", gocui.KeySpace, toggleReplay(app)},
+		{"sysstat", '(', stepReplay(app, -1)},
+		{"sysstat", ')', stepReplay(app, 1)},
+		{"sysstat", '+', changeReplaySpeed(app, 2)},
+		{"sysstat", '-', changeReplaySpeed(app, 0.5)},
+		{"sysstat", 'g', dialogOpen(app, dialogReplaySeek)},
+	}
+
+	for _, k := range []interface{}{
+		gocui.KeyEnter, ',', 'I', 'Q', 'E', 'l', 'C', '~', 'B', 'N', 'L', 'R', '_', 'n', 'm', 'k', 'K', 'A', 'G', 'z', 'W',
+	} {
+		keys = append(keys, key{"sysstat", k, replayNotAvailable})
+	}
+
+	return keys
+}
@@ -0,0 +1,49 @@
+package top
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func Test_readOnlyKeys(t *testing.T) {
+	app := &app{config: newConfig()}
+	keys := readOnlyKeys(liveKeys(app))
+	assert.Len(t, keys, len(liveKeys(app)))
+
+	var n int
+	disabled := reflect.ValueOf(readOnlyNotAvailable).Pointer()
+	for _, k := range keys {
+		got := reflect.ValueOf(k.handler).Pointer() == disabled
+		assert.Equal(t, readOnlyActions[k.key], got, "key %c", k.key)
+		if got {
+			n++
+		}
+	}
+
+	// All mutating actions are bound in live mode.
+	assert.Equal(t, len(readOnlyActions), n)
+}
+
+func Test_readOnlyGuards(t *testing.T) {
+	config := newConfig()
+	config.readOnly = true
+
+	// Handlers return without touching connection (nil connection would panic).
+	assert.NoError(t, resetStat(nil, true, config)(nil, nil))
+	assert.NoError(t, runPsql(nil, nil, config)(nil, nil))
+	assert.NoError(t, editPgConfig(nil, nil, gucMainConfFile, nil, config))
+
+	for d, want := range map[dialogType]bool{
+		dialogPgReload:         true,
+		dialogCancelQuery:      true,
+		dialogTerminateBackend: true,
+		dialogCancelGroup:      true,
+		dialogTerminateGroup:   true,
+		dialogFilter:           false,
+		dialogSetMask:          false,
+		dialogChangeRefresh:    false,
+	} {
+		assert.Equal(t, want, isMutatingDialog(d), d)
+	}
+}
@@ -17,10 +17,17 @@ const (
 	menuPgss                     // menu with pg_stat_statements stats
 	menuProgress                 // menu with pg_stat_progress_* stats
 	menuConf                     // menu with configuration files
+	menuCustom                   // menu with user-defined views
 
-	// Directions allowed when working with menu.
-	moveUp   direction = iota // move up
-	moveDown                  // move down
+	// Directions allowed when working with menu or moving over rows of stats.
+	moveUp       direction = iota // move up
+	moveDown                      // move down
+	movePageUp                    // move one page up
+	movePageDown                  // move one page down
+	moveHome                      // move to the first row
+	moveEnd                       // move to the last row
+	moveLeft                      // move left
+	moveRight                     // move right
 )
 
 // menuStyle describes menu properties.
@@ -68,6 +75,12 @@ func selectMenuStyle(t menuType) menuStyle {
 				" recovery.conf",
 			},
 		}
+	case menuCustom:
+		// Items are built from user-defined views when menu is opened.
+		s = menuStyle{
+			menuType: menuCustom,
+			title:    " Choose user-defined view (Enter to choose, Esc to exit): ",
+		}
 	default:
 		s = menuStyle{
 			menuType: menuNone,
@@ -88,6 +101,17 @@ func menuOpen(m menuType, config *config, pgssAvail bool) func(g *gocui.Gui, _ *
 			return nil
 		}
 
+		if s.menuType == menuCustom {
+			for _, name := range config.views.CustomNames() {
+				s.items = append(s.items, " "+name)
+			}
+
+			if len(s.items) == 0 {
+				printCmdline(g, "NOTICE: no user-defined views available")
+				return nil
+			}
+		}
+
 		v, err := g.SetView("menu", 0, 5, 72, 6+len(s.items))
 		if err != nil {
 			if err != gocui.ErrUnknownView {
@@ -141,22 +165,28 @@ func menuSelect(app *app) func(g *gocui.Gui, v *gocui.View) error {
 				viewSwitchHandler(app.config, "progress_index")
 			}
 			printCmdline(app.ui, app.config.view.Msg)
+		case menuCustom:
+			names := app.config.views.CustomNames()
+			if cy < len(names) {
+				viewSwitchHandler(app.config, names[cy])
+				printCmdline(app.ui, app.config.view.Msg)
+			}
 		case menuConf:
 			switch cy {
 			case 0:
-				if err := editPgConfig(g, app.db, gucMainConfFile, app.uiExit); err != nil {
+				if err := editPgConfig(g, app.db, gucMainConfFile, app.uiExit, app.config); err != nil {
 					return err
 				}
 			case 1:
-				if err := editPgConfig(g, app.db, gucHbaFile, app.uiExit); err != nil {
+				if err := editPgConfig(g, app.db, gucHbaFile, app.uiExit, app.config); err != nil {
 					return err
 				}
 			case 2:
-				if err := editPgConfig(g, app.db, gucIdentFile, app.uiExit); err != nil {
+				if err := editPgConfig(g, app.db, gucIdentFile, app.uiExit, app.config); err != nil {
 					return err
 				}
 			case 3:
-				if err := editPgConfig(g, app.db, gucRecoveryFile, app.uiExit); err != nil {
+				if err := editPgConfig(g, app.db, gucRecoveryFile, app.uiExit, app.config); err != nil {
 					return err
 				}
 			}
@@ -14,6 +14,7 @@ func Test_selectMenuStyle(t *testing.T) {
 		{menu: menuPgss, want: 5},
 		{menu: menuProgress, want: 3},
 		{menu: menuConf, want: 4},
+		{menu: menuCustom, want: 0},
 	}
 
 	for _, tc := range testcases {
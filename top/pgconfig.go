@@ -66,8 +66,16 @@ func showPgConfig(db *postgres.DB, uiExit chan int) func(g *gocui.Gui, _ *gocui.
 	}
 }
 
-// editPgConfig opens specified configuration file in $EDITOR program.
-func editPgConfig(g *gocui.Gui, db *postgres.DB, filename string, uiExit chan int) error {
+// editPgConfig opens specified configuration file in $EDITOR program. Editing is recorded in audit log. Editing is
+// not available in read-only mode.
+func editPgConfig(g *gocui.Gui, db *postgres.DB, filename string, uiExit chan int, config *config) error {
+	if config.isReadOnly() {
+		printCmdline(g, readOnlyMessage)
+		return nil
+	}
+
+	audit := config.audit
+
 	if !db.Local {
 		printCmdline(g, "Edit config is not supported for remote hosts")
 		return nil
@@ -97,13 +105,30 @@ func editPgConfig(g *gocui.Gui, db *postgres.DB, filename string, uiExit chan in
 	uiExit <- 1
 	g.Close()
 
+	// Checksum is used for recording whether the file has been modified.
+	var before string
+	if audit != nil {
+		before = fileChecksum(configFile)
+	}
+
 	cmd := exec.Command(editor, configFile) // #nosec G204
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 
 	if err := cmd.Run(); err != nil {
+		rec := newAuditRecord(db, "edit_config", fmt.Sprintf("Edit: run editor failed, %s", err))
+		rec.File = configFile
+		_ = audit.write(db, rec)
 		return fmt.Errorf("run editor failed: %s", err)
 	}
 
+	if audit != nil {
+		rec := newAuditRecord(db, "edit_config", editResult(before, fileChecksum(configFile)))
+		rec.File = configFile
+		if err := audit.write(db, rec); err != nil {
+			return fmt.Errorf("write audit record failed: %s", err)
+		}
+	}
+
 	return nil
 }
@@ -10,9 +10,14 @@ import (
 	"strconv"
 )
 
-// runPsql starts psql session to the current connected database.
-func runPsql(db *postgres.DB, uiExit chan int) func(g *gocui.Gui, _ *gocui.View) error {
+// runPsql starts psql session to the current connected database. Session is not started in read-only mode.
+func runPsql(db *postgres.DB, uiExit chan int, config *config) func(g *gocui.Gui, _ *gocui.View) error {
 	return func(g *gocui.Gui, _ *gocui.View) error {
+		if config.isReadOnly() {
+			printCmdline(g, readOnlyMessage)
+			return nil
+		}
+
 		// Ignore interrupts in pgCenter, because Ctrl+C in psql interrupts pgCenter.
 		signal.Ignore(os.Interrupt)
 
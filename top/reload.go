@@ -7,8 +7,9 @@ import (
 	"github.com/lesovsky/pgcenter/internal/query"
 )
 
-// doReload performs reload of Postgres service by executing pg_reload_conf().
-func doReload(answer string, db *postgres.DB) string {
+// doReload performs reload of Postgres service by executing pg_reload_conf(). Performed reload is recorded in audit
+// log.
+func doReload(answer string, db *postgres.DB, audit *auditLog) string {
 	var message string
 
 	switch answer {
@@ -18,7 +19,7 @@ func doReload(answer string, db *postgres.DB) string {
 		err := db.QueryRow(query.ExecReloadConf).Scan(&status)
 		if err != nil {
 			message = fmt.Sprintf("Reload: failed, %s", err.Error())
-			return message
+			return audit.record(db, "reload", nil, message)
 		}
 
 		if status.Bool {
@@ -26,6 +27,7 @@ func doReload(answer string, db *postgres.DB) string {
 		} else {
 			message = "Reload: no error, got NULL response"
 		}
+		message = audit.record(db, "reload", nil, message)
 	case "n":
 		message = "Reload: do nothing, canceled"
 	default:
@@ -20,10 +20,10 @@ func Test_doReload(t *testing.T) {
 	assert.NoError(t, err)
 
 	for _, tc := range testcases {
-		assert.Equal(t, tc.want, doReload(tc.answer, conn))
+		assert.Equal(t, tc.want, doReload(tc.answer, conn, nil))
 	}
 
 	// Test with closed conn
 	conn.Close()
-	assert.Equal(t, "Reload: failed, conn closed", doReload(testcases[0].answer, conn))
+	assert.Equal(t, "Reload: failed, conn closed", doReload(testcases[0].answer, conn, nil))
 }
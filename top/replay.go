@@ -0,0 +1,604 @@
+package top
+
+import (
+	"context"
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// replayTick defines how often replay position is advanced during playback.
+	replayTick = 100 * time.Millisecond
+
+	// replaySpeedMin and replaySpeedMax define allowed range of playback speed.
+	replaySpeedMin = 0.25
+	replaySpeedMax = 64
+)
+
+// replaySample describes stats snapshot stored in the recorded file.
+type replaySample struct {
+	ts    time.Time // time when stats have been recorded
+	epoch int       // number of gaps in recorded stats before the snapshot, snapshots of different epochs aren't compared
+}
+
+// replayer reads stats snapshots from files recorded by 'pgcenter record' and plays them back.
+type replayer struct {
+	filename string                    // name of the recorded file, directory or pattern of rotated files
+	samples  map[string][]replaySample // snapshots per view ordered by time
+	start    time.Time                 // time of the first recorded snapshot
+	end      time.Time                 // time of the last recorded snapshot
+
+	mu      sync.Mutex    // protects fields below, they are used by UI handlers and replay goroutine
+	view    string        // name of the replayed view
+	pos     time.Time     // current replay position
+	playing bool          // playback is running
+	speed   float64       // playback speed relative to recorded time
+	nudge   chan struct{} // used for signaling position has been changed by user
+}
+
+// newReplayer builds index of snapshots stored in recorded files.
+func newReplayer(filename string) (*replayer, error) {
+	r := &replayer{
+		filename: filename,
+		samples:  map[string][]replaySample{},
+		speed:    1,
+		nudge:    make(chan struct{}, 1),
+	}
+
+	err := r.index()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// index reads names of all stored snapshots and remembers their time. Snapshots recorded before and after the gap
+// in recorded stats are assigned to different epochs.
+func (r *replayer) index() error {
+	files, err := archive.ListArchives(r.filename)
+	if err != nil {
+		return err
+	}
+
+	var epoch int
+	for _, filename := range files {
+		entries, err := indexEntries(filename)
+		if err != nil {
+			return fmt.Errorf("read %s failed: %s", filename, err)
+		}
+
+		for _, e := range entries {
+			switch {
+			case e.View == archive.GapEntryName:
+				epoch++
+			case isServiceEntry(e.View):
+				continue
+			default:
+				r.add(e.View, replaySample{ts: e.Ts, epoch: epoch})
+			}
+		}
+	}
+
+	return r.finishIndex()
+}
+
+// indexEntries returns entries of the archive. Archive's index is used if possible, it allows to avoid reading the
+// whole archive.
+func indexEntries(filename string) ([]archive.IndexEntry, error) {
+	if entries, ok := loadIndex(filename); ok {
+		return entries, nil
+	}
+
+	ar, err := archive.NewReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = ar.Close() }()
+
+	var entries []archive.IndexEntry
+	for {
+		hdr, err := ar.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		name, ts, err := archive.ParseEntryName(hdr.Name)
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, archive.IndexEntry{Name: hdr.Name, View: name, Ts: ts})
+	}
+
+	return entries, nil
+}
+
+// loadIndex loads entries of the archive from its index. Returns false if there is no index or the index doesn't
+// describe the whole archive, e.g. when recording has been interrupted or archive is compressed.
+func loadIndex(filename string) ([]archive.IndexEntry, bool) {
+	entries, err := archive.ReadIndex(filename)
+	if err != nil {
+		return nil, false
+	}
+
+	st, err := os.Stat(filename)
+	if err != nil {
+		return nil, false
+	}
+
+	// Archive ends with the data of the last entry padded to the tar block size and the end-of-archive marker.
+	last := entries[len(entries)-1]
+	if last.Offset < 0 || (last.Offset+last.Size+511)/512*512+1024 != st.Size() {
+		return nil, false
+	}
+
+	return entries, true
+}
+
+// isServiceEntry returns true if the entry doesn't contain stats of the view which could be replayed.
+func isServiceEntry(name string) bool {
+	for _, reserved := range archive.ReservedEntryNames {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// add remembers the view's snapshot.
+func (r *replayer) add(name string, s replaySample) {
+	r.samples[name] = append(r.samples[name], s)
+
+	if r.start.IsZero() || s.ts.Before(r.start) {
+		r.start = s.ts
+	}
+	if s.ts.After(r.end) {
+		r.end = s.ts
+	}
+}
+
+// finishIndex checks snapshots are found and prepares them for replaying.
+func (r *replayer) finishIndex() error {
+	if len(r.samples) == 0 {
+		return fmt.Errorf("no stats found in %s", r.filename)
+	}
+
+	for name := range r.samples {
+		s := r.samples[name]
+		sort.SliceStable(s, func(i, j int) bool { return s[i].ts.Before(s[j].ts) })
+	}
+
+	r.pos = r.start
+	return nil
+}
+
+// read reads the view's snapshot and the previous snapshot it should be compared with. The previous snapshot is not
+// read if there is a gap in recorded stats between snapshots. Stats written as deltas are restored using the
+// preceding full stats.
+func (r *replayer) read(name string, i int) (stat.PGresult, stat.PGresult, error) {
+	samples := r.samples[name]
+	from, to := samples[i].ts, samples[i].ts
+	if i > 0 && samples[i-1].epoch == samples[i].epoch {
+		from = samples[i-1].ts
+	}
+
+	ar, err := archive.NewReader(r.filename)
+	if err != nil {
+		return stat.PGresult{}, stat.PGresult{}, err
+	}
+	defer func() { _ = ar.Close() }()
+
+	ar.Seek(name, from, to)
+
+	var curr, prev stat.PGresult
+	var dec archive.Decoder
+	for {
+		hdr, err := ar.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return stat.PGresult{}, stat.PGresult{}, err
+		}
+
+		view, ts, err := archive.ParseEntryName(hdr.Name)
+		if err != nil || view != name {
+			continue
+		}
+
+		delta := archive.IsDeltaEntry(hdr.Name)
+
+		if ts.After(to) {
+			break
+		}
+
+		// Stats out of the interval might be required for restoring the next stats written as deltas.
+		if ts.Before(from) {
+			err := dec.Skip(ar, hdr.Size, delta)
+			if err != nil {
+				return stat.PGresult{}, stat.PGresult{}, fmt.Errorf("read %s failed: %s", hdr.Name, err)
+			}
+			continue
+		}
+
+		res, err := dec.Read(ar, hdr.Size, delta)
+		if err != nil {
+			return stat.PGresult{}, stat.PGresult{}, fmt.Errorf("read %s failed: %s", hdr.Name, err)
+		}
+
+		if ts.Equal(to) {
+			curr = res
+			break
+		}
+		prev = res
+	}
+
+	if !curr.Valid {
+		return stat.PGresult{}, stat.PGresult{}, fmt.Errorf("stats of %s recorded at %s not found", name, to.Format("15:04:05"))
+	}
+
+	return curr, prev, nil
+}
+
+// current returns index of the view's snapshot corresponding to current position - the latest snapshot recorded
+// not after the position. The first snapshot is skipped when possible, because there is nothing to compare it with.
+// Returns -1 if there are no snapshots of the view. Must be called with the lock held.
+func (r *replayer) current(name string) int {
+	samples := r.samples[name]
+	if len(samples) == 0 {
+		return -1
+	}
+
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].ts.After(r.pos) }) - 1
+	if i < 1 {
+		i = 0
+		if len(samples) > 1 {
+			i = 1
+		}
+	}
+
+	return i
+}
+
+// snapshot returns stats of the view corresponding to current position.
+func (r *replayer) snapshot(v view.View) snapshot {
+	r.mu.Lock()
+	i, pos := r.current(v.Name), r.pos
+	r.mu.Unlock()
+
+	if i < 0 {
+		return snapshot{ts: pos, stat: stat.Stat{Error: fmt.Errorf("no recorded stats for view %s", v.Name)}}
+	}
+
+	samples := r.samples[v.Name]
+
+	curr, prev, err := r.read(v.Name, i)
+	if err != nil {
+		return snapshot{ts: samples[i].ts, stat: stat.Stat{Error: err}}
+	}
+
+	// Snapshots separated by gap are not compared, the first snapshot after the gap is shown as-is.
+	var itv = 1
+	if prev.Valid {
+		if secs := int(samples[i].ts.Sub(samples[i-1].ts).Seconds()); secs > 1 {
+			itv = secs
+		}
+	}
+
+	// Views could be recorded by other version of Postgres, don't rely on numbers of columns known in advance.
+	if v.OrderKey >= curr.Ncols {
+		v.OrderKey = 0
+	}
+
+	if v.KeepOrder {
+		return snapshot{ts: samples[i].ts, stat: stat.Stat{Pgstat: stat.Pgstat{Result: curr}}}
+	}
+
+	res, err := stat.Compare(curr, prev, itv, v.DiffIntvl, v.OrderKey, v.OrderDesc, v.UniqueKey)
+	if err != nil {
+		return snapshot{ts: samples[i].ts, stat: stat.Stat{Error: err}}
+	}
+
+	return snapshot{ts: samples[i].ts, stat: stat.Stat{Pgstat: stat.Pgstat{Result: res}}}
+}
+
+// advance moves replay position forward accordingly to elapsed time and playback speed. Returns true if
+// position moved to the next snapshot of the view or playback has been stopped.
+func (r *replayer) advance(elapsed time.Duration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.playing {
+		return false
+	}
+
+	i := r.current(r.view)
+	r.pos = r.pos.Add(time.Duration(float64(elapsed) * r.speed))
+
+	// Stop playback at the end of recording.
+	if !r.pos.Before(r.end) {
+		r.pos = r.end
+		r.playing = false
+		return true
+	}
+
+	return r.current(r.view) != i
+}
+
+// togglePlay starts or stops playback. Playback started at the end of recording is restarted from the beginning.
+func (r *replayer) togglePlay() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.playing = !r.playing
+	if r.playing && !r.pos.Before(r.end) {
+		r.pos = r.start
+	}
+
+	r.notify()
+}
+
+// step moves position to previous (negative) or next (positive) snapshot of the view, playback is stopped when
+// stepping. Returns false if there is no snapshots in requested direction.
+func (r *replayer) step(n int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.playing = false
+
+	samples := r.samples[r.view]
+	i := r.current(r.view) + n
+	if i < 0 || i >= len(samples) || (i == 0 && len(samples) > 1) {
+		return false
+	}
+
+	r.pos = samples[i].ts
+	r.notify()
+	return true
+}
+
+// seek moves position to specified time, the time is clamped to recording's time range.
+func (r *replayer) seek(ts time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case ts.Before(r.start):
+		r.pos = r.start
+	case ts.After(r.end):
+		r.pos = r.end
+	default:
+		r.pos = ts
+	}
+
+	r.notify()
+}
+
+// changeSpeed multiplies playback speed by factor, the speed is clamped to allowed range.
+func (r *replayer) changeSpeed(factor float64) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.speed *= factor
+	if r.speed < replaySpeedMin {
+		r.speed = replaySpeedMin
+	}
+	if r.speed > replaySpeedMax {
+		r.speed = replaySpeedMax
+	}
+
+	return r.speed
+}
+
+// notify signals replay goroutine to re-read snapshot at current position. Must be called with the lock held.
+func (r *replayer) notify() {
+	select {
+	case r.nudge <- struct{}{}:
+	default:
+	}
+}
+
+// status returns description of the current replay state.
+func (r *replayer) status() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := "paused"
+	if r.playing {
+		state = "playing"
+	}
+
+	i, n := r.current(r.view)+1, len(r.samples[r.view])
+	return fmt.Sprintf("Replay: %s, speed x%g, snapshot %d of %d (%s)", state, r.speed, i, n, r.pos.Format("15:04:05"))
+}
+
+// run reads snapshots of requested views and sends them to snapshot channel. Snapshots are sent when position
+// changes during playback, when position is changed by user or when view is changed.
+func (r *replayer) run(ctx context.Context, snapCh chan<- snapshot, viewCh <-chan view.View) {
+	var v view.View
+
+	select {
+	case v = <-viewCh:
+	case <-ctx.Done():
+		return
+	}
+
+	send := func() bool {
+		r.mu.Lock()
+		r.view = v.Name
+		r.mu.Unlock()
+
+		select {
+		case snapCh <- r.snapshot(v):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if !send() {
+		return
+	}
+
+	ticker := time.NewTicker(replayTick)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		var ok = true
+
+		select {
+		case v = <-viewCh:
+			ok = send()
+		case <-r.nudge:
+			ok = send()
+		case now := <-ticker.C:
+			if r.advance(now.Sub(last)) {
+				ok = send()
+			}
+			last = now
+		case <-ctx.Done():
+			return
+		}
+
+		if !ok {
+			return
+		}
+	}
+}
+
+// doReplay runs playback of recorded stats and prints received snapshots.
+func doReplay(ctx context.Context, app *app) {
+	var wg sync.WaitGroup
+	snapCh := make(chan snapshot)
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Stop replay goroutine and wait until it finishes when leaving.
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		app.replay.run(ctx, snapCh, app.config.viewCh)
+		wg.Done()
+	}()
+
+	// Send default view to replay goroutine.
+	app.config.viewCh <- app.config.view
+
+	for {
+		select {
+		case <-app.uiExit:
+			// used for exit from UI (not the program).
+			return
+		case s := <-snapCh:
+			printStat(app, s.stat, s.ts)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// printReplay prints information about replayed file instead of Postgres summary stats.
+func printReplay(v *gocui.View, r *replayer) error {
+	_, err := fmt.Fprintf(v, "replay: \033[37;1m%s\033[0m\n", r.filename)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(v, " range: \033[37;1m%s - %s\033[0m\n", r.start.Format("2006-01-02 15:04:05"), r.end.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(v, "status: %s\n", strings.TrimPrefix(r.status(), "Replay: "))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(v, "  keys: Space play/pause, ( ) step, + - speed, g seek")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// toggleReplay starts or stops playback of recorded stats.
+func toggleReplay(app *app) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		app.replay.togglePlay()
+		printCmdline(g, app.replay.status())
+		return nil
+	}
+}
+
+// stepReplay shows previous or next recorded snapshot.
+func stepReplay(app *app, n int) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		if !app.replay.step(n) {
+			if n < 0 {
+				printCmdline(g, "Replay: no previous snapshots")
+			} else {
+				printCmdline(g, "Replay: no next snapshots")
+			}
+			return nil
+		}
+
+		printCmdline(g, app.replay.status())
+		return nil
+	}
+}
+
+// changeReplaySpeed changes playback speed of recorded stats.
+func changeReplaySpeed(app *app, factor float64) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		printCmdline(g, "Replay: speed x%g", app.replay.changeSpeed(factor))
+		return nil
+	}
+}
+
+// seekReplay moves replay position to time specified by user.
+func seekReplay(answer string, r *replayer) string {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "Replay: do nothing"
+	}
+
+	ts, err := time.ParseInLocation("2006-01-02 15:04:05", answer, time.Now().Location())
+	if err != nil {
+		// Only time is specified, use date of current position.
+		t, err := time.ParseInLocation("15:04:05", answer, time.Now().Location())
+		if err != nil {
+			return "Replay: invalid time, use format [YYYY-MM-DD ]HH:MM:SS"
+		}
+
+		r.mu.Lock()
+		y, m, d := r.pos.Date()
+		r.mu.Unlock()
+
+		ts = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.Now().Location())
+	}
+
+	r.seek(ts)
+	return r.status()
+}
+
+// replayNotAvailable is the UI handler used for actions which can't be performed when replaying recorded stats.
+func replayNotAvailable(g *gocui.Gui, _ *gocui.View) error {
+	printCmdline(g, "Not available in replay mode.")
+	return nil
+}
@@ -0,0 +1,249 @@
+package top
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"github.com/lesovsky/pgcenter/internal/archive"
+	"github.com/lesovsky/pgcenter/internal/stat"
+	"github.com/lesovsky/pgcenter/internal/view"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testReplayEntry describes entry of recorded file, value is the value of 'xact_commit' or the reason of the gap.
+type testReplayEntry struct {
+	name  string
+	value string
+}
+
+// writeTestReplayEntries writes entries of recorded file.
+func writeTestReplayEntries(t *testing.T, w io.Writer, entries []testReplayEntry) {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		res := stat.PGresult{
+			Valid: true,
+			Ncols: 2,
+			Nrows: 1,
+			Cols:  []string{"datname", "xact_commit"},
+			Values: [][]sql.NullString{
+				{{String: "example", Valid: true}, {String: e.value, Valid: true}},
+			},
+		}
+
+		data, err := json.Marshal(res)
+		assert.NoError(t, err)
+
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0644, Size: int64(len(data))}))
+		_, err = tw.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+}
+
+// newTestReplayFile creates recorded file with three snapshots of 'databases' view.
+func newTestReplayFile(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "pgcenter-replay-")
+	assert.NoError(t, err)
+
+	filename := filepath.Join(dir, "pgcenter.stat.tar")
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+
+	writeTestReplayEntries(t, f, []testReplayEntry{
+		{name: "databases.20211001T100000.json", value: "100"},
+		{name: "databases.20211001T100010.json", value: "200"},
+		{name: "databases.20211001T100020.json", value: "400"},
+	})
+	assert.NoError(t, f.Close())
+
+	return filename, func() { _ = os.RemoveAll(dir) }
+}
+
+func Test_replayer(t *testing.T) {
+	filename, cleanup := newTestReplayFile(t)
+	defer cleanup()
+
+	r, err := newReplayer(filename)
+	assert.NoError(t, err)
+
+	assert.Len(t, r.samples["databases"], 3)
+	assert.Equal(t, 20, int(r.end.Sub(r.start).Seconds()))
+
+	v := view.View{Name: "databases", DiffIntvl: [2]int{1, 1}, UniqueKey: 0, OrderKey: 1, OrderDesc: true}
+	r.view = v.Name
+
+	// At start, the second snapshot is shown compared with the first one.
+	s := r.snapshot(v)
+	assert.NoError(t, s.stat.Error)
+	assert.Equal(t, "10", s.stat.Result.Values[0][1].String)
+
+	// Step forward and back.
+	assert.True(t, r.step(1))
+	s = r.snapshot(v)
+	assert.Equal(t, "20", s.stat.Result.Values[0][1].String)
+	assert.False(t, r.step(1))
+	assert.True(t, r.step(-1))
+	assert.False(t, r.step(-1))
+
+	// Playback.
+	r.togglePlay()
+	assert.True(t, r.playing)
+	assert.False(t, r.advance(5*time.Second))
+	assert.Equal(t, 4.0, r.changeSpeed(4))
+	assert.True(t, r.advance(10*time.Second))
+	assert.False(t, r.playing)
+	assert.Equal(t, r.end, r.pos)
+	assert.Equal(t, float64(replaySpeedMin), r.changeSpeed(0.01))
+
+	// Seeking.
+	assert.Contains(t, seekReplay("10:00:12", r), "snapshot 2 of 3")
+	assert.Contains(t, seekReplay("2021-10-01 09:00:00", r), "snapshot 2 of 3")
+	assert.Equal(t, r.start, r.pos)
+	assert.Contains(t, seekReplay("invalid", r), "invalid time")
+
+	// Missing view.
+	s = r.snapshot(view.View{Name: "tables"})
+	assert.Error(t, s.stat.Error)
+
+	// Invalid file.
+	_, err = newReplayer("/nonexistent")
+	assert.Error(t, err)
+}
+
+func Test_loadIndex(t *testing.T) {
+	filename, cleanup := newTestReplayFile(t)
+	defer cleanup()
+
+	// No index.
+	_, ok := loadIndex(filename)
+	assert.False(t, ok)
+
+	// Build index using locations of snapshots found by reading the whole file.
+	f, err := os.Open(filename)
+	assert.NoError(t, err)
+
+	var entries []archive.IndexEntry
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+
+		offset, err := f.Seek(0, io.SeekCurrent)
+		assert.NoError(t, err)
+		entries = append(entries, archive.IndexEntry{Name: hdr.Name, Snapshot: offset - 512, Offset: offset, Size: hdr.Size})
+	}
+	assert.NoError(t, f.Close())
+
+	idx, err := os.Create(archive.IndexName(filename))
+	assert.NoError(t, err)
+	assert.NoError(t, archive.WriteIndex(idx, entries))
+	assert.NoError(t, idx.Close())
+
+	// Snapshots are loaded from the index and replayed the same way as read from the whole file.
+	got, ok := loadIndex(filename)
+	assert.True(t, ok)
+	assert.Len(t, got, 3)
+	assert.Equal(t, "databases", got[0].View)
+
+	r, err := newReplayer(filename)
+	assert.NoError(t, err)
+	assert.Len(t, r.samples["databases"], 3)
+	assert.Equal(t, 20, int(r.end.Sub(r.start).Seconds()))
+
+	// Index doesn't describe snapshots written after it.
+	f, err = os.OpenFile(filename, os.O_RDWR|os.O_APPEND, 0600)
+	assert.NoError(t, err)
+	_, err = f.Write(make([]byte, 512))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	_, ok = loadIndex(filename)
+	assert.False(t, ok)
+}
+
+func Test_replayer_gap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-replay-")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	filename := filepath.Join(dir, "pgcenter.stat.tar")
+	f, err := os.Create(filename)
+	assert.NoError(t, err)
+
+	writeTestReplayEntries(t, f, []testReplayEntry{
+		{name: "databases.20211001T100000.json", value: "100"},
+		{name: "databases.20211001T100010.json", value: "200"},
+		{name: "gap.20211001T100015.json", value: "connection lost"},
+		{name: "databases.20211001T100020.json", value: "400"},
+		{name: "databases.20211001T100030.json", value: "500"},
+	})
+	assert.NoError(t, f.Close())
+
+	r, err := newReplayer(filename)
+	assert.NoError(t, err)
+	assert.Len(t, r.samples, 1)
+	assert.Len(t, r.samples["databases"], 4)
+
+	v := view.View{Name: "databases", DiffIntvl: [2]int{1, 1}, UniqueKey: 0, OrderKey: 1, OrderDesc: true}
+	r.view = v.Name
+
+	// Stats recorded before and after the gap are not compared.
+	var want = []string{"10", "400", "10"}
+	for i := range want {
+		s := r.snapshot(v)
+		assert.NoError(t, s.stat.Error)
+		assert.Equal(t, want[i], s.stat.Result.Values[0][1].String)
+		r.step(1)
+	}
+}
+
+func Test_replayer_rotated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-replay-")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// The first archive is plain, the second one is compressed.
+	f, err := os.Create(filepath.Join(dir, "pgcenter.stat.20211001T100000.tar"))
+	assert.NoError(t, err)
+	writeTestReplayEntries(t, f, []testReplayEntry{
+		{name: "databases.20211001T100000.json", value: "100"},
+		{name: "databases.20211001T100010.json", value: "200"},
+	})
+	assert.NoError(t, f.Close())
+
+	f, err = os.Create(filepath.Join(dir, "pgcenter.stat.20211001T100020.tar.gz"))
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	writeTestReplayEntries(t, gz, []testReplayEntry{
+		{name: "databases.20211001T100020.json", value: "400"},
+		{name: "databases.20211001T100030.json", value: "800"},
+	})
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, f.Close())
+
+	r, err := newReplayer(dir)
+	assert.NoError(t, err)
+	assert.Len(t, r.samples["databases"], 4)
+
+	v := view.View{Name: "databases", DiffIntvl: [2]int{1, 1}, UniqueKey: 0, OrderKey: 1, OrderDesc: true}
+	r.view = v.Name
+
+	// Snapshots are compared across archives.
+	var want = []string{"10", "20", "40"}
+	for i := range want {
+		s := r.snapshot(v)
+		assert.NoError(t, s.stat.Error)
+		assert.Equal(t, want[i], s.stat.Result.Values[0][1].String)
+		r.step(1)
+	}
+}
@@ -106,13 +106,110 @@ func getQueryReport(answer string, version int, db *postgres.DB) (report, string
 
 // printQueryReport prints report in $PAGER program.
 func printQueryReport(g *gocui.Gui, r report, uiExit chan int) string {
-	t, err := template.New("query").Parse(reportTemplate)
+	return printReportPager(g, "query", reportTemplate, r, uiExit)
+}
+
+// tableReport defines data container with per-table report values.
+type tableReport struct {
+	Relation         string // schema-qualified name of the table
+	TotalSize        string // total size of the table including indexes and TOAST
+	TableSize        string // size of the table's main fork
+	IndexesSize      string // size of all table's indexes
+	ToastSize        string // size of the table's TOAST including TOAST index
+	Live             string // estimated number of live rows
+	Dead             string // estimated number of dead rows
+	DeadRatio        string // ratio of dead rows to total number of rows
+	SeqScan          string // number of sequential scans
+	SeqRead          string // number of rows fetched by sequential scans
+	IdxScan          string // number of index scans
+	IdxFetch         string // number of rows fetched by index scans
+	Inserts          string // number of rows inserted
+	Updates          string // number of rows updated
+	Deletes          string // number of rows deleted
+	HotUpdates       string // number of rows HOT updated
+	HeapRead         string // number of blocks read from disk
+	HeapHit          string // number of blocks found in shared buffers
+	LastVacuum       string // last time the table was manually vacuumed
+	LastAutovacuum   string // last time the table was vacuumed by autovacuum
+	LastAnalyze      string // last time the table was manually analyzed
+	LastAutoanalyze  string // last time the table was analyzed by autovacuum
+	VacuumCount      string // number of manual vacuums
+	AutovacuumCount  string // number of vacuums made by autovacuum
+	AnalyzeCount     string // number of manual analyzes
+	AutoanalyzeCount string // number of analyzes made by autovacuum
+}
+
+const (
+	// tableReportTemplate is the template for the per-table report shown to user
+	tableReportTemplate = `table: {{.Relation}}
+
+sizes:
+    total:                 {{.TotalSize}}
+        table:             {{.TableSize}}
+        indexes:           {{.IndexesSize}}
+        toast:             {{.ToastSize}}
+
+rows:
+    live:                  {{.Live}}
+    dead:                  {{.Dead}},  {{.DeadRatio}}%
+
+access:
+    seq scans:             {{.SeqScan}}, rows read: {{.SeqRead}}
+    index scans:           {{.IdxScan}}, rows fetched: {{.IdxFetch}}
+    heap blocks:           {{.HeapRead}} read, {{.HeapHit}} hit
+
+modifications:
+    inserts:               {{.Inserts}}
+    updates:               {{.Updates}}, hot: {{.HotUpdates}}
+    deletes:               {{.Deletes}}
+
+maintenance:
+    last vacuum:           {{.LastVacuum}}, total: {{.VacuumCount}}
+    last autovacuum:       {{.LastAutovacuum}}, total: {{.AutovacuumCount}}
+    last analyze:          {{.LastAnalyze}}, total: {{.AnalyzeCount}}
+    last autoanalyze:      {{.LastAutoanalyze}}, total: {{.AutoanalyzeCount}}
+`
+)
+
+// getTableReport queries tables stats, generate the report and returns it.
+func getTableReport(relation string, db *postgres.DB) (tableReport, string) {
+	if relation == "" {
+		return tableReport{}, "Report: do nothing"
+	}
+
+	var r tableReport
+	err := db.QueryRow(query.PgStatTableReportQuery, relation).Scan(
+		&r.Relation, &r.TotalSize, &r.TableSize, &r.IndexesSize, &r.ToastSize,
+		&r.Live, &r.Dead, &r.DeadRatio, &r.SeqScan, &r.SeqRead, &r.IdxScan, &r.IdxFetch,
+		&r.Inserts, &r.Updates, &r.Deletes, &r.HotUpdates, &r.HeapRead, &r.HeapHit,
+		&r.LastVacuum, &r.LastAutovacuum, &r.LastAnalyze, &r.LastAutoanalyze,
+		&r.VacuumCount, &r.AutovacuumCount, &r.AnalyzeCount, &r.AutoanalyzeCount,
+	)
+
+	if err == pgx.ErrNoRows {
+		return tableReport{}, "Report: no statistics for such table"
+	}
+	if err != nil {
+		return tableReport{}, "Report: " + err.Error()
+	}
+
+	return r, ""
+}
+
+// printTableReport prints per-table report in $PAGER program.
+func printTableReport(g *gocui.Gui, r tableReport, uiExit chan int) string {
+	return printReportPager(g, "table", tableReportTemplate, r, uiExit)
+}
+
+// printReportPager renders report using passed template and prints it in $PAGER program.
+func printReportPager(g *gocui.Gui, name string, tmpl string, data interface{}, uiExit chan int) string {
+	t, err := template.New(name).Parse(tmpl)
 	if err != nil {
 		return err.Error()
 	}
 
 	var buf bytes.Buffer
-	err = t.Execute(&buf, r)
+	err = t.Execute(&buf, data)
 	if err != nil {
 		return err.Error()
 	}
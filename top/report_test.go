@@ -30,3 +30,22 @@ func Test_getQueryReport(t *testing.T) {
 		assert.Equal(t, tc.want, got)
 	}
 }
+
+func Test_getTableReport(t *testing.T) {
+	conn, err := postgres.NewTestConnect()
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		relation string
+		want     string
+	}{
+		{relation: "pg_catalog.pg_class", want: ""},
+		{relation: "", want: "Report: do nothing"},
+		{relation: "invalid.invalid", want: "Report: no statistics for such table"},
+	}
+
+	for _, tc := range testcases {
+		_, got := getTableReport(tc.relation, conn)
+		assert.Equal(t, tc.want, got)
+	}
+}
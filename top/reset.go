@@ -9,9 +9,14 @@ import (
 
 // resetStat resets Postgres stats counters.
 // Reset statistics that belongs to current database and pg_stat_statements stats.
-// Don't reset shared stats, such as bgwriter or archiver.
-func resetStat(db *postgres.DB, pgssAvail bool) func(g *gocui.Gui, _ *gocui.View) error {
+// Don't reset shared stats, such as bgwriter or archiver. Performed reset is recorded in audit log.
+func resetStat(db *postgres.DB, pgssAvail bool, config *config) func(g *gocui.Gui, _ *gocui.View) error {
 	return func(g *gocui.Gui, _ *gocui.View) error {
+		if config.isReadOnly() {
+			printCmdline(g, readOnlyMessage)
+			return nil
+		}
+
 		msg := "Reset statistics."
 
 		_, err := db.Exec(query.ExecResetStats)
@@ -26,7 +31,7 @@ func resetStat(db *postgres.DB, pgssAvail bool) func(g *gocui.Gui, _ *gocui.View
 			}
 		}
 
-		printCmdline(g, msg)
+		printCmdline(g, config.audit.record(db, "reset_stats", nil, msg))
 
 		return nil
 	}
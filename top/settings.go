@@ -0,0 +1,279 @@
+package top
+
+import (
+	"fmt"
+	"github.com/jroimartin/gocui"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// settingsFilename defines name of the file with saved settings, the file is looked up in user's config directory.
+	settingsFilename = "pgcenter/top.yaml"
+
+	// defaultProfile defines name of the settings profile used by default.
+	defaultProfile = "default"
+)
+
+// settingsFile defines content of the file with saved settings. Settings are grouped into named profiles.
+type settingsFile struct {
+	Profiles map[string]settings `yaml:"profiles"`
+}
+
+// settings defines 'top' runtime settings could be saved and restored.
+type settings struct {
+	View             string                  `yaml:"view"`               // Name of the active view
+	Refresh          time.Duration           `yaml:"refresh"`            // Stats refresh interval
+	QueryAge         string                  `yaml:"query_age"`          // Queries age threshold used in activity view
+	ProcMask         string                  `yaml:"proc_mask"`          // Process mask used for selecting group of process
+	HideIdle         bool                    `yaml:"hide_idle"`          // Don't show idle connections in activity view
+	ShowSystemTables bool                    `yaml:"show_system_tables"` // Show system tables and indexes
+	ReadOnly         bool                    `yaml:"read_only"`          // Disable actions which change state of Postgres
+	Views            map[string]viewSettings `yaml:"views"`              // Per-view settings
+}
+
+// viewSettings defines per-view settings.
+type viewSettings struct {
+	OrderKey  int            `yaml:"order_key"`            // Index of column used for order
+	OrderDesc bool           `yaml:"order_desc"`           // Order direction
+	ColsWidth map[int]int    `yaml:"cols_width,omitempty"` // Width of columns
+	Filters   map[int]string `yaml:"filters,omitempty"`    // Filter patterns: key is the column index, value - regexp pattern
+}
+
+// defaultSettingsFile returns path to default file with saved settings.
+func defaultSettingsFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, settingsFilename)
+}
+
+// newSettings creates settings from current runtime configuration.
+func newSettings(config *config) settings {
+	s := settings{
+		View:             config.view.Name,
+		Refresh:          config.refresh,
+		QueryAge:         config.queryOptions.QueryAgeThresh,
+		ProcMask:         maskToString(config.procMask),
+		HideIdle:         config.queryOptions.ShowNoIdle,
+		ShowSystemTables: config.queryOptions.ViewType == "all",
+		ReadOnly:         config.readOnlySet,
+		Views:            map[string]viewSettings{},
+	}
+
+	// Current view contains the most recent settings.
+	views := map[string]bool{config.view.Name: true}
+	s.Views[config.view.Name] = newViewSettings(config.view.OrderKey, config.view.OrderDesc, config.view.ColsWidth, config.view.Filters)
+
+	for name, v := range config.views {
+		if views[name] {
+			continue
+		}
+		s.Views[name] = newViewSettings(v.OrderKey, v.OrderDesc, v.ColsWidth, v.Filters)
+	}
+
+	return s
+}
+
+// newViewSettings creates per-view settings.
+func newViewSettings(orderKey int, orderDesc bool, widths map[int]int, filters map[int]*regexp.Regexp) viewSettings {
+	vs := viewSettings{OrderKey: orderKey, OrderDesc: orderDesc}
+
+	if len(widths) > 0 {
+		vs.ColsWidth = map[int]int{}
+		for k, w := range widths {
+			vs.ColsWidth[k] = w
+		}
+	}
+
+	for k, re := range filters {
+		if re == nil {
+			continue
+		}
+		if vs.Filters == nil {
+			vs.Filters = map[int]string{}
+		}
+		vs.Filters[k] = re.String()
+	}
+
+	return vs
+}
+
+// apply applies settings to runtime configuration. Queries are re-formatted accordingly to restored query options.
+func (s settings) apply(config *config) error {
+	if s.Refresh >= time.Second {
+		config.refresh = s.Refresh
+	}
+
+	if s.QueryAge != "" {
+		err := parseHumanTimeString(s.QueryAge)
+		if err != nil {
+			return fmt.Errorf("invalid query age: %s", err)
+		}
+		config.queryOptions.QueryAgeThresh = s.QueryAge
+	}
+
+	config.procMask = parseProcMask(s.ProcMask)
+
+	// Read-only mode enabled by command line option is kept regardless of the profile.
+	config.readOnlySet = s.ReadOnly
+	config.queryOptions.ShowNoIdle = s.HideIdle
+	if s.ShowSystemTables {
+		config.queryOptions.ViewType = "all"
+	} else {
+		config.queryOptions.ViewType = "user"
+	}
+
+	// Re-create queries accordingly to restored options.
+	err := config.views.Configure(config.queryOptions)
+	if err != nil {
+		return err
+	}
+
+	for name, vs := range s.Views {
+		v, ok := config.views[name]
+		if !ok {
+			continue
+		}
+
+		if vs.OrderKey >= 0 && (v.Ncols == 0 || vs.OrderKey < v.Ncols) {
+			v.OrderKey = vs.OrderKey
+			v.OrderDesc = vs.OrderDesc
+		}
+
+		// Restored widths are used instead of calculated ones when view is aligned.
+		for k, w := range vs.ColsWidth {
+			if w > 0 && w <= colsWidthMax {
+				v.ColsWidth[k] = w
+			}
+		}
+
+		for k, pattern := range vs.Filters {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid filter in view %s: %s", name, err)
+			}
+			v.Filters[k] = re
+		}
+
+		config.views[name] = v
+	}
+
+	// Switch to saved view, or refresh current view with re-created query.
+	if v, ok := config.views[s.View]; ok {
+		config.view = v
+	} else {
+		config.view = config.views[config.view.Name]
+	}
+
+	return nil
+}
+
+// readSettingsFile reads settings file. Not existing file is considered as empty.
+func readSettingsFile(filename string) (settingsFile, error) {
+	f := settingsFile{Profiles: map[string]settings{}}
+
+	data, err := ioutil.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return f, err
+	}
+
+	err = yaml.Unmarshal(data, &f)
+	if err != nil {
+		return f, fmt.Errorf("parse %s failed: %s", filename, err)
+	}
+
+	if f.Profiles == nil {
+		f.Profiles = map[string]settings{}
+	}
+
+	return f, nil
+}
+
+// loadSettings reads settings profile from file and applies it to runtime configuration. Nothing is applied if
+// profile is not found.
+func loadSettings(filename string, profile string, config *config) error {
+	f, err := readSettingsFile(filename)
+	if err != nil {
+		return err
+	}
+
+	s, ok := f.Profiles[profile]
+	if !ok {
+		return nil
+	}
+
+	err = s.apply(config)
+	if err != nil {
+		return fmt.Errorf("apply settings profile '%s' failed: %s", profile, err)
+	}
+
+	return nil
+}
+
+// writeSettings writes settings profile to file, other profiles in the file are kept as-is.
+func writeSettings(filename string, profile string, s settings) error {
+	f, err := readSettingsFile(filename)
+	if err != nil {
+		return err
+	}
+
+	f.Profiles[profile] = s
+
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Clean(filename), data, 0600)
+}
+
+// saveSettings is the UI handler which saves current settings into the profile.
+func saveSettings(config *config) func(g *gocui.Gui, _ *gocui.View) error {
+	return func(g *gocui.Gui, _ *gocui.View) error {
+		if config.settingsFile == "" {
+			printCmdline(g, "Settings: do nothing, settings file is not defined")
+			return nil
+		}
+
+		err := writeSettings(config.settingsFile, config.profile, newSettings(config))
+		if err != nil {
+			printCmdline(g, "Settings: save failed, %s", err)
+			return nil
+		}
+
+		printCmdline(g, "Settings: saved to profile '%s'", config.profile)
+		return nil
+	}
+}
+
+// maskToString converts process mask to string using the same letters used when setting up the mask.
+func maskToString(mask int) string {
+	var b strings.Builder
+	for _, m := range []struct {
+		flag int
+		ch   byte
+	}{
+		{groupActive, 'a'}, {groupIdle, 'i'}, {groupIdleXact, 'x'}, {groupWaiting, 'w'}, {groupOthers, 'o'},
+	} {
+		if mask&m.flag != 0 {
+			b.WriteByte(m.ch)
+		}
+	}
+	return b.String()
+}
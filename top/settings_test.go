@@ -0,0 +1,123 @@
+package top
+
+import (
+	"github.com/lesovsky/pgcenter/internal/query"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_settings_roundtrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-settings-")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	filename := filepath.Join(dir, "pgcenter", "top.yaml")
+
+	// Prepare configuration with non-default settings.
+	config := newConfig()
+	config.queryOptions = query.NewOptions(130000, "f", "off", 256)
+	assert.NoError(t, config.views.Configure(config.queryOptions))
+	config.view = config.views["tables"]
+	config.view.OrderKey = 3
+	config.view.OrderDesc = false
+	config.view.ColsWidth[0] = 40
+	config.view.Filters[0] = regexp.MustCompile("^public")
+	config.refresh = 5 * time.Second
+	config.queryOptions.QueryAgeThresh = "00:00:10"
+	config.queryOptions.ShowNoIdle = true
+	config.queryOptions.ViewType = "all"
+	config.procMask = groupActive | groupWaiting
+
+	assert.NoError(t, writeSettings(filename, "testing", newSettings(config)))
+	assert.NoError(t, writeSettings(filename, "other", settings{View: "databases"}))
+
+	// Restore settings into default configuration.
+	restored := newConfig()
+	restored.queryOptions = query.NewOptions(130000, "f", "off", 256)
+	assert.NoError(t, restored.views.Configure(restored.queryOptions))
+	restored.view = restored.views["activity"]
+
+	assert.NoError(t, loadSettings(filename, "testing", restored))
+	assert.Equal(t, "tables", restored.view.Name)
+	assert.Equal(t, 3, restored.view.OrderKey)
+	assert.False(t, restored.view.OrderDesc)
+	assert.Equal(t, 40, restored.view.ColsWidth[0])
+	assert.Equal(t, "^public", restored.view.Filters[0].String())
+	assert.Equal(t, 5*time.Second, restored.refresh)
+	assert.Equal(t, "00:00:10", restored.queryOptions.QueryAgeThresh)
+	assert.True(t, restored.queryOptions.ShowNoIdle)
+	assert.Equal(t, "all", restored.queryOptions.ViewType)
+	assert.Contains(t, restored.view.Query, "pg_stat_all_tables")
+	assert.Equal(t, groupActive|groupWaiting, restored.procMask)
+
+	// Other profiles are kept.
+	assert.NoError(t, loadSettings(filename, "other", restored))
+	assert.Equal(t, "databases", restored.view.Name)
+
+	// Unknown profile changes nothing.
+	assert.NoError(t, loadSettings(filename, "unknown", restored))
+	assert.Equal(t, "databases", restored.view.Name)
+}
+
+func Test_settings_apply_invalid(t *testing.T) {
+	testcases := []settings{
+		{QueryAge: "invalid"},
+		{Views: map[string]viewSettings{"activity": {Filters: map[int]string{0: "["}}}},
+	}
+
+	for _, tc := range testcases {
+		config := newConfig()
+		config.queryOptions = query.NewOptions(130000, "f", "off", 256)
+		assert.Error(t, tc.apply(config))
+	}
+}
+
+func Test_maskToString(t *testing.T) {
+	assert.Equal(t, "", maskToString(0))
+	assert.Equal(t, "aixwo", maskToString(groupActive|groupIdle|groupIdleXact|groupWaiting|groupOthers))
+	assert.Equal(t, groupIdle|groupOthers, parseProcMask(maskToString(groupIdle|groupOthers)))
+}
+
+func Test_settings_readOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcenter-settings-")
+	assert.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	filename := filepath.Join(dir, "top.yaml")
+
+	// Read-only mode enabled by command line option is not saved into profile.
+	config := newConfig()
+	config.queryOptions = query.NewOptions(130000, "f", "off", 256)
+	config.readOnly = true
+	assert.NoError(t, writeSettings(filename, "flag", newSettings(config)))
+
+	// Read-only mode enabled by profile is saved.
+	config = newConfig()
+	config.queryOptions = query.NewOptions(130000, "f", "off", 256)
+	config.readOnlySet = true
+	assert.NoError(t, writeSettings(filename, "readonly", newSettings(config)))
+
+	restored := newConfig()
+	restored.queryOptions = query.NewOptions(130000, "f", "off", 256)
+	assert.NoError(t, loadSettings(filename, "flag", restored))
+	assert.False(t, restored.isReadOnly())
+
+	assert.NoError(t, loadSettings(filename, "readonly", restored))
+	assert.True(t, restored.isReadOnly())
+
+	// Read-only mode enabled by profile could be disabled by profile.
+	restored.readOnlySet = false
+	assert.NoError(t, writeSettings(filename, "readonly", newSettings(restored)))
+	assert.NoError(t, loadSettings(filename, "readonly", restored))
+	assert.False(t, restored.isReadOnly())
+
+	// Read-only mode enabled by command line option is kept regardless of the profile.
+	restored.readOnly = true
+	assert.NoError(t, loadSettings(filename, "readonly", restored))
+	assert.True(t, restored.isReadOnly())
+}
@@ -16,8 +16,8 @@ const (
 	groupOthers
 )
 
-// killSingle sends cancel or terminate signal to a single Postgres backend.
-func killSingle(db *postgres.DB, mode string, answer string) string {
+// killSingle sends cancel or terminate signal to a single Postgres backend. The signal is recorded in audit log.
+func killSingle(db *postgres.DB, mode string, answer string, audit *auditLog) string {
 	if mode != "cancel" && mode != "terminate" {
 		return "Signals: do nothing, unknown mode"
 	}
@@ -36,15 +36,22 @@ func killSingle(db *postgres.DB, mode string, answer string) string {
 		return fmt.Sprintf("Signals: do nothing, %s", err.Error())
 	}
 
-	_, err = db.Exec(q, pid)
+	// Remember query of the backend for audit, query is left empty if the backend doesn't exist.
+	targets := []auditTarget{{Pid: pid}}
+	if audit != nil {
+		_ = db.QueryRow(query.GetBackendQuery, pid).Scan(&targets[0].Query)
+	}
+
+	err = db.QueryRow(q, pid).Scan(&targets[0].Signalled)
 	if err != nil {
-		return fmt.Sprintf("Signals: do nothing, %s", err.Error())
+		return audit.record(db, mode, targets, fmt.Sprintf("Signals: do nothing, %s", err.Error()))
 	}
 
-	return "Signals: done"
+	return audit.record(db, mode, targets, "Signals: done")
 }
 
-// killGroup sends cancel or terminate signal to group of Postgres backends.
+// killGroup sends cancel or terminate signal to group of Postgres backends. Signalled backends are recorded in
+// audit log.
 func killGroup(app *app, mode string) string {
 	if app.config.view.Name != "activity" {
 		return "Signals: sending signals allowed in pg_stat_activity only"
@@ -79,7 +86,7 @@ func killGroup(app *app, mode string) string {
 	}
 
 	// Walk through the states, if state is in the mask then send signal to that group of process.
-	var signalled, signalledTotal int64
+	var targets []auditTarget
 	for state, part := range states {
 		if (mask & state) != 0 {
 			app.config.queryOptions.BackendState = part
@@ -94,47 +101,81 @@ func killGroup(app *app, mode string) string {
 			}
 
 			// execute query
-			err = app.db.QueryRow(q).Scan(&signalled)
+			signalled, err := signalGroup(app.db, q)
+			targets = append(targets, signalled...)
 			if err != nil {
-				return fmt.Sprintf("Signals: %s", err.Error())
+				return app.config.audit.record(app.db, mode+"_group", targets, fmt.Sprintf("Signals: %s", err.Error()))
 			}
+		}
+	}
 
-			signalledTotal += signalled
+	// Only successfully signalled backends are counted, all matched backends are recorded in audit log.
+	var signalled int
+	for _, t := range targets {
+		if t.Signalled {
+			signalled++
 		}
 	}
 
 	var msg string
 	switch mode {
 	case "cancel":
-		msg = "Signals: cancelled " + strconv.FormatInt(signalledTotal, 10) + " queries."
+		msg = "Signals: cancelled " + strconv.Itoa(signalled) + " queries."
 	case "terminate":
-		msg = "Signals: terminated " + strconv.FormatInt(signalledTotal, 10) + " backends."
+		msg = "Signals: terminated " + strconv.Itoa(signalled) + " backends."
 	}
 
-	return msg
+	return app.config.audit.record(app.db, mode+"_group", targets, msg)
+}
+
+// signalGroup executes query which sends signal to group of backends and returns matched backends with results of
+// signalling.
+func signalGroup(db *postgres.DB, q string) ([]auditTarget, error) {
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []auditTarget
+	for rows.Next() {
+		var t auditTarget
+		if err := rows.Scan(&t.Pid, &t.Query, &t.Signalled); err != nil {
+			return targets, err
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, rows.Err()
 }
 
 // setProcMask set process mask.
 func setProcMask(answer string, config *config) string {
-	// Reset existing mask.
-	config.procMask = 0
+	config.procMask = parseProcMask(answer)
 
-	for _, ch := range answer {
+	return printMaskString(config.procMask)
+}
+
+// parseProcMask converts string with mask letters to process mask.
+func parseProcMask(s string) int {
+	var mask int
+
+	for _, ch := range s {
 		switch string(ch) {
 		case "i":
-			config.procMask |= groupIdle
+			mask |= groupIdle
 		case "x":
-			config.procMask |= groupIdleXact
+			mask |= groupIdleXact
 		case "a":
-			config.procMask |= groupActive
+			mask |= groupActive
 		case "w":
-			config.procMask |= groupWaiting
+			mask |= groupWaiting
 		case "o":
-			config.procMask |= groupOthers
+			mask |= groupOthers
 		}
 	}
 
-	return printMaskString(config.procMask)
+	return mask
 }
 
 // showProcMask UI-wrapper over printMaskString.
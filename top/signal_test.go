@@ -34,11 +34,11 @@ func Test_killSingle(t *testing.T) {
 	assert.NoError(t, err)
 
 	for _, tc := range testcases {
-		assert.Equal(t, tc.want, killSingle(db, tc.mode, tc.pid))
+		assert.Equal(t, tc.want, killSingle(db, tc.mode, tc.pid, nil))
 	}
 
 	db.Close()
-	assert.Equal(t, "Signals: do nothing, conn closed", killSingle(db, "cancel", pid))
+	assert.Equal(t, "Signals: do nothing, conn closed", killSingle(db, "cancel", pid, nil))
 }
 
 func Test_killGroup(t *testing.T) {
@@ -2,6 +2,7 @@ package top
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
@@ -13,11 +14,37 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// refreshSettings tracks stats refresh interval. Views might define their own refresh interval, other views use
+// the global interval.
+type refreshSettings struct {
+	global  time.Duration // global refresh interval, set at start or changed by user
+	current time.Duration // refresh interval of the current view
+}
+
+// switchView selects refresh interval for the view. Returns true if the interval has been changed.
+func (r *refreshSettings) switchView(v view.View) bool {
+	prev := r.current
+	if v.Refresh > 0 {
+		r.current = v.Refresh
+	} else {
+		r.current = r.global
+	}
+	return r.current != prev
+}
+
+// setGlobal changes global refresh interval, the current interval is changed only if the view doesn't define its
+// own interval. Returns true if the current interval has been changed.
+func (r *refreshSettings) setGlobal(v view.View, interval time.Duration) bool {
+	r.global = interval
+	return r.switchView(v)
+}
+
 // collectStat
-func collectStat(ctx context.Context, db *postgres.DB, statCh chan<- stat.Stat, viewCh <-chan view.View) {
+func collectStat(ctx context.Context, db *postgres.DB, statCh chan<- stat.Stat, viewCh <-chan view.View, refreshCh <-chan time.Duration, global time.Duration) {
 	c, err := stat.NewCollector(db)
 	if err != nil {
 		fmt.Println(err)
@@ -30,8 +57,10 @@ func collectStat(ctx context.Context, db *postgres.DB, statCh chan<- stat.Stat,
 	// Enable collecting of extra stats if it's specified in the view.
 	c.ToggleCollectExtra(v.ShowExtra)
 
-	// Set refresh interval from received view.
-	refresh := v.Refresh
+	// Set refresh interval from received view, or use the global one.
+	r := refreshSettings{global: global}
+	r.switchView(v)
+	refresh := r.current
 
 	// Run first update to prefill "previous" snapshot.
 	_, err = c.Update(db, v, refresh)
@@ -46,6 +75,7 @@ func collectStat(ctx context.Context, db *postgres.DB, statCh chan<- stat.Stat,
 
 	// Set settings related to extra stats.
 	extra := v.ShowExtra
+	name := v.Name
 
 	// Collect stat in loop and send it to stat channel.
 	for {
@@ -60,12 +90,23 @@ func collectStat(ctx context.Context, db *postgres.DB, statCh chan<- stat.Stat,
 		// settings to adjust collector's behavior.
 		ticker := time.NewTicker(refresh)
 		select {
+		case interval := <-refreshCh:
+			ticker.Stop()
+			r.setGlobal(v, interval)
+			refresh = r.current
+			continue
 		case v = <-viewCh:
-			// Update refresh interval if it is changed.
-			if refresh != v.Refresh && v.Refresh > 0 {
-				refresh = v.Refresh
-				continue
+			// Update refresh interval if it is changed. Views might have their own refresh interval, in this case
+			// refresh is changed when switching to the view and stats have to be re-initialized. When switching
+			// back to views without own interval the global interval is restored.
+			if r.switchView(v) {
+				refresh = r.current
+				if name == v.Name {
+					ticker.Stop()
+					continue
+				}
 			}
+			name = v.Name
 
 			// Update settings related to collecting extra stats (enable, disable or switch)
 			if extra != v.ShowExtra {
@@ -93,95 +134,112 @@ func collectStat(ctx context.Context, db *postgres.DB, statCh chan<- stat.Stat,
 	}
 }
 
-// printStat prints collected stats in UI.
-func printStat(app *app, s stat.Stat, props stat.PostgresProperties) {
+// printStat prints collected stats in UI. Stats are also saved in history, when display is paused they are not printed.
+func printStat(app *app, s stat.Stat, ts time.Time) {
 	app.ui.Update(func(g *gocui.Gui) error {
-		v, err := g.View("sysstat")
-		if err != nil {
-			return fmt.Errorf("set focus on sysstat view failed: %s", err)
-		}
-		v.Clear()
-		err = printSysstat(v, s)
-		if err != nil {
-			return fmt.Errorf("print sysstat failed: %s", err)
+		app.config.history.add(s, ts)
+		if app.config.history.paused {
+			return nil
 		}
 
-		v, err = g.View("pgstat")
-		if err != nil {
-			return fmt.Errorf("set focus on pgstat view failed: %s", err)
-		}
-		v.Clear()
-		err = printPgstat(v, s, props, app.db)
-		if err != nil {
-			return fmt.Errorf("print summary postgres stat failed: %s", err)
-		}
+		return drawStat(g, app, s, ts)
+	})
+}
 
-		v, err = g.View("dbstat")
-		if err != nil {
-			return fmt.Errorf("set focus on dbstat view failed: %s", err)
-		}
-		v.Clear()
+// drawStat prints stats snapshot in UI.
+func drawStat(g *gocui.Gui, app *app, s stat.Stat, ts time.Time) error {
+	v, err := g.View("sysstat")
+	if err != nil {
+		return fmt.Errorf("set focus on sysstat view failed: %s", err)
+	}
+	v.Clear()
+	err = printSysstat(v, s, ts)
+	if err != nil {
+		return fmt.Errorf("print sysstat failed: %s", err)
+	}
+
+	v, err = g.View("pgstat")
+	if err != nil {
+		return fmt.Errorf("set focus on pgstat view failed: %s", err)
+	}
+	v.Clear()
+	if app.replay != nil {
+		err = printReplay(v, app.replay)
+	} else {
+		err = printPgstat(v, s, app.postgresProps, app.db)
+	}
+	if err != nil {
+		return fmt.Errorf("print summary postgres stat failed: %s", err)
+	}
+
+	v, err = g.View("dbstat")
+	if err != nil {
+		return fmt.Errorf("set focus on dbstat view failed: %s", err)
+	}
+	v.Clear()
+
+	// Remember printed stats, it allows to redraw stats when user moves cursor over rows.
+	app.config.lastStat = s
+
+	err = printDbstat(v, app.config, s, dbstatRowsLimit(g, v, app.config))
+	if err != nil {
+		return fmt.Errorf("print main postgres stat failed: %s", err)
+	}
 
-		err = printDbstat(v, app.config, s)
+	if app.config.view.ShowExtra > stat.CollectNone {
+		v, err := g.View("extra")
 		if err != nil {
-			return fmt.Errorf("print main postgres stat failed: %s", err)
+			return fmt.Errorf("set focus on extra view failed: %s", err)
 		}
 
-		if app.config.view.ShowExtra > stat.CollectNone {
-			v, err := g.View("extra")
+		switch app.config.view.ShowExtra {
+		case stat.CollectDiskstats:
+			v.Clear()
+			err := printIostat(v, s.Diskstats)
 			if err != nil {
-				return fmt.Errorf("set focus on extra view failed: %s", err)
+				return err
+			}
+		case stat.CollectNetdev:
+			v.Clear()
+			err := printNetdev(v, s.Netdevs)
+			if err != nil {
+				return err
+			}
+		case stat.CollectLogtail:
+			size, buf, err := readLogfileRecent(v, app.config.logtail)
+			if err != nil {
+				printCmdline(g, "Tail Postgres log failed: %s", err)
+				return err
 			}
 
-			switch app.config.view.ShowExtra {
-			case stat.CollectDiskstats:
-				v.Clear()
-				err := printIostat(v, s.Diskstats)
-				if err != nil {
-					return err
-				}
-			case stat.CollectNetdev:
+			if size < app.config.logtail.Size {
 				v.Clear()
-				err := printNetdev(v, s.Netdevs)
-				if err != nil {
-					return err
-				}
-			case stat.CollectLogtail:
-				size, buf, err := readLogfileRecent(v, app.config.logtail)
+				err := app.config.logtail.Reopen(app.db, app.postgresProps.VersionNum)
 				if err != nil {
 					printCmdline(g, "Tail Postgres log failed: %s", err)
 					return err
 				}
+			}
 
-				if size < app.config.logtail.Size {
-					v.Clear()
-					err := app.config.logtail.Reopen(app.db, app.postgresProps.VersionNum)
-					if err != nil {
-						printCmdline(g, "Tail Postgres log failed: %s", err)
-						return err
-					}
-				}
-
-				// Update info about logfile size.
-				app.config.logtail.Size = size
+			// Update info about logfile size.
+			app.config.logtail.Size = size
 
-				err = printLogtail(v, app.config.logtail.Path, buf)
-				if err != nil {
-					return err
-				}
+			err = printLogtail(v, app.config.logtail.Path, buf)
+			if err != nil {
+				return err
 			}
 		}
-		return nil
-	})
+	}
+	return nil
 }
 
 // printSysstat prints system stats on UI.
-func printSysstat(v *gocui.View, s stat.Stat) error {
+func printSysstat(v *gocui.View, s stat.Stat, ts time.Time) error {
 	var err error
 
 	/* line1: current time and load average */
 	_, err = fmt.Fprintf(v, "pgcenter: %s, load average: %.2f, %.2f, %.2f\n",
-		time.Now().Format("2006-01-02 15:04:05"),
+		ts.Format("2006-01-02 15:04:05"),
 		s.LoadAvg.One, s.LoadAvg.Five, s.LoadAvg.Fifteen)
 	if err != nil {
 		return err
@@ -269,8 +327,30 @@ func formatInfoString(cfg postgres.Config, state, version, uptime, recovery stri
 	)
 }
 
-// printDbstat prints main Postgres stats on UI.
-func printDbstat(v *gocui.View, config *config, s stat.Stat) error {
+// dbstatRowsLimit returns number of stats rows which fit on the screen. When extra stats are shown, they overlap
+// bottom part of 'dbstat' view.
+func dbstatRowsLimit(g *gocui.Gui, v *gocui.View, config *config) int {
+	_, y := v.Size()
+	limit := y - 1 // one line is used by the header
+
+	if config.view.ShowExtra > stat.CollectNone {
+		_, y0, _, _, err := g.ViewPosition(v.Name())
+		if err != nil {
+			return limit
+		}
+		_, ey0, _, _, err := g.ViewPosition("extra")
+		if err != nil {
+			return limit
+		}
+		limit = ey0 - y0 - 2
+	}
+
+	return limit
+}
+
+// printDbstat prints main Postgres stats on UI. Limit specifies the max number of rows which could be printed, zero
+// or negative limit means no limits.
+func printDbstat(v *gocui.View, config *config, s stat.Stat, limit int) error {
 	// If reading stats failed, print the error occurred and return.
 	if s.Error != nil {
 		_, err := fmt.Fprint(v, formatError(s.Error))
@@ -284,19 +364,37 @@ func printDbstat(v *gocui.View, config *config, s stat.Stat) error {
 	// Align values within columns, use fixed aligning instead of dynamic.
 	if !config.view.Aligned {
 		widthes, cols := align.SetAlign(s.Result, 1000, false) // use high limit (1000) to avoid truncating last value.
+
+		// Widths restored from saved settings take precedence over calculated ones.
+		for i, w := range config.view.ColsWidth {
+			if _, ok := widthes[i]; ok {
+				widthes[i] = w
+			}
+		}
+
 		config.view.Cols = cols
 		config.view.ColsWidth = widthes
 		config.view.Aligned = true
+
+		// Number of columns in user-defined views is not known in advance, activity view of local Postgres is
+		// extended with OS stats of backends.
+		if config.view.Ncols == 0 || (config.view.Name == "activity" && s.Result.Ncols > config.view.Ncols) {
+			config.view.Ncols = s.Result.Ncols
+		}
 	}
 
+	// Select columns which fit into the screen.
+	width, _ := v.Size()
+	cols := config.cursor.visibleColumns(config.view.ColsWidth, s.Result.Ncols, width)
+
 	// Print header.
-	err := printStatHeader(v, s, config)
+	err := printStatHeader(v, s, config, cols)
 	if err != nil {
 		return err
 	}
 
 	// Print data.
-	err = printStatData(v, s, config, isFilterRequired(config.view.Filters))
+	err = printStatData(v, s, config, isFilterRequired(config.view.Filters), limit, cols)
 	if err != nil {
 		return err
 	}
@@ -318,10 +416,10 @@ func formatError(err error) string {
 	return fmt.Sprintf("ERROR: %s", err.Error())
 }
 
-// printStatHeader prints stats header.
-func printStatHeader(v *gocui.View, s stat.Stat, config *config) error {
+// printStatHeader prints header of specified columns.
+func printStatHeader(v *gocui.View, s stat.Stat, config *config, cols []int) error {
 	var pname string
-	for i := 0; i < s.Result.Ncols; i++ {
+	for _, i := range cols {
 		name := s.Result.Cols[i]
 
 		// mark filtered column
@@ -352,12 +450,13 @@ func printStatHeader(v *gocui.View, s stat.Stat, config *config) error {
 	return nil
 }
 
-// printStatData prints stats data.
-func printStatData(v *gocui.View, s stat.Stat, config *config, filter bool) error {
-	var doPrint bool
-	for colnum, rownum := 0, 0; rownum < s.Result.Nrows; rownum, colnum = rownum+1, 0 {
+// printStatData prints stats data. Only rows and columns visible on the screen are printed, the selected row is highlighted.
+func printStatData(v *gocui.View, s stat.Stat, config *config, filter bool, limit int, cols []int) error {
+	// Select rows should be printed.
+	rows := make([][]sql.NullString, 0, s.Result.Nrows)
+	for rownum := 0; rownum < s.Result.Nrows; rownum++ {
 		// be optimistic, we want to print the row.
-		doPrint = true
+		doPrint := true
 
 		// apply filters using regexp
 		if filter {
@@ -373,30 +472,42 @@ func printStatData(v *gocui.View, s stat.Stat, config *config, filter bool) erro
 			}
 		}
 
-		// print values
-		for i := range s.Result.Cols {
-			if doPrint {
-				// truncate values that longer than column width
-				valuelen := len(s.Result.Values[rownum][colnum].String)
-				if valuelen > config.view.ColsWidth[i] {
-					width := config.view.ColsWidth[i]
-					// truncate value up to column width and replace last character with '~' symbol
-					s.Result.Values[rownum][colnum].String = s.Result.Values[rownum][colnum].String[:width-1] + "~"
-				}
+		if doPrint {
+			rows = append(rows, s.Result.Values[rownum])
+		}
+	}
 
-				// print value
-				_, err := fmt.Fprintf(v, "%-*s", config.view.ColsWidth[i]+2, s.Result.Values[rownum][colnum].String)
-				if err != nil {
-					return err
-				}
-				colnum++
+	// Place cursor on the selected row and calculate visible part of rows.
+	config.cursor.sync(rows, config.view.UniqueKey, limit)
+
+	first, last := config.cursor.offset, len(rows)
+	if limit > 0 && first+limit < last {
+		last = first + limit
+	}
+
+	for rownum := first; rownum < last; rownum++ {
+		var line strings.Builder
+		for _, colnum := range cols {
+			value := rows[rownum][colnum].String
+
+			// truncate values that longer than column width and replace last character with '~' symbol
+			width := config.view.ColsWidth[colnum]
+			if width > 0 && len(value) > width {
+				value = value[:width-1] + "~"
 			}
+
+			line.WriteString(fmt.Sprintf("%-*s", width+2, value))
 		}
-		if doPrint {
-			_, err := fmt.Fprintf(v, "\n")
-			if err != nil {
-				return err
-			}
+
+		// highlight selected row
+		var err error
+		if rownum == config.cursor.row {
+			_, err = fmt.Fprintf(v, "\033[30;46m%s\033[0m\n", line.String())
+		} else {
+			_, err = fmt.Fprintf(v, "%s\n", line.String())
+		}
+		if err != nil {
+			return err
 		}
 	}
 
@@ -5,10 +5,42 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/lesovsky/pgcenter/internal/postgres"
+	"github.com/lesovsky/pgcenter/internal/view"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
+func Test_refreshSettings(t *testing.T) {
+	views := view.New()
+	custom := view.View{Name: "queues", Custom: true, Refresh: 5 * time.Second}
+
+	r := refreshSettings{global: time.Second}
+	assert.True(t, r.switchView(views["activity"]))
+	assert.Equal(t, time.Second, r.current)
+
+	// Custom view uses its own interval.
+	assert.True(t, r.switchView(custom))
+	assert.Equal(t, 5*time.Second, r.current)
+
+	// Built-in view restores the global interval.
+	assert.True(t, r.switchView(views["databases"]))
+	assert.Equal(t, time.Second, r.current)
+
+	// Changing global interval in custom view doesn't change the current interval, but the changed interval is used
+	// after switching to built-in view.
+	assert.False(t, r.switchView(views["databases"]))
+	assert.True(t, r.switchView(custom))
+	assert.False(t, r.setGlobal(custom, 2*time.Second))
+	assert.Equal(t, 5*time.Second, r.current)
+	assert.True(t, r.switchView(views["activity"]))
+	assert.Equal(t, 2*time.Second, r.current)
+
+	// Changing global interval in built-in view changes the current interval.
+	assert.True(t, r.setGlobal(views["activity"], 3*time.Second))
+	assert.Equal(t, 3*time.Second, r.current)
+}
+
 func Test_formatInfoString(t *testing.T) {
 	testcases := []struct {
 		cfg  postgres.Config
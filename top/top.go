@@ -3,13 +3,56 @@ package top
 import (
 	"context"
 	"github.com/jroimartin/gocui"
+	"github.com/lesovsky/pgcenter/internal/archive"
 	"github.com/lesovsky/pgcenter/internal/postgres"
 	"github.com/lesovsky/pgcenter/internal/query"
 	"github.com/lesovsky/pgcenter/internal/stat"
 )
 
+// Config defines 'pgcenter top' startup configuration.
+type Config struct {
+	ViewsFile   string // File with user-defined views
+	Profile     string // Name of the settings profile
+	HistorySize int    // Number of stats snapshots kept in history
+	ReplayFile  string // File with recorded stats, when specified stats are replayed instead of reading from Postgres
+	ReadOnly    bool   // Disable actions which change state of Postgres: signals to backends, reload, stats reset, etc.
+	AuditFile   string // File where actions which change state of Postgres are recorded
+	AuditTarget string // Record actions which change state of Postgres into Postgres log or notification channel
+}
+
 // RunMain is the main entry point for 'pgcenter top' command
-func RunMain(dbConfig postgres.Config) error {
+func RunMain(dbConfig postgres.Config, config Config) error {
+	// Load user-defined views.
+	c := newConfig()
+	err := c.views.LoadCustom(config.ViewsFile, archive.ReservedEntryNames)
+	if err != nil {
+		return err
+	}
+
+	// Setup stats history.
+	if config.HistorySize > 0 {
+		c.history = newHistory(config.HistorySize)
+	}
+
+	// Setup settings profile.
+	c.settingsFile = defaultSettingsFile()
+	if config.Profile != "" {
+		c.profile = config.Profile
+	}
+
+	c.readOnly = config.ReadOnly
+
+	// Setup audit of administrative actions.
+	c.audit, err = newAuditLog(config.AuditFile, config.AuditTarget)
+	if err != nil {
+		return err
+	}
+
+	// Replay recorded stats, connection to Postgres is not required.
+	if config.ReplayFile != "" {
+		return runReplay(config.ReplayFile, c)
+	}
+
 	// Connect to Postgres.
 	db, err := postgres.Connect(dbConfig)
 	if err != nil {
@@ -18,7 +61,7 @@ func RunMain(dbConfig postgres.Config) error {
 	defer db.Close()
 
 	// Create application instance.
-	app := newApp(db, newConfig())
+	app := newApp(db, c)
 
 	// Setup application.
 	err = app.setup()
@@ -38,6 +81,7 @@ type app struct {
 	uiError       error                   // hold error occurred during executing UI.
 	db            *postgres.DB            // connection to Postgres.
 	postgresProps stat.PostgresProperties // properties of Postgres to which connected to.
+	replay        *replayer               // replayed recorded stats, nil when stats are read from Postgres.
 }
 
 // newApp creates new application instance.
@@ -72,6 +116,49 @@ func (app *app) setup() error {
 	app.postgresProps = props
 	app.uiExit = make(chan int)
 
+	// Restore saved settings.
+	if app.config.settingsFile != "" {
+		err = loadSettings(app.config.settingsFile, app.config.profile, app.config)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runReplay runs application which replays stats recorded in the file.
+func runReplay(filename string, c *config) error {
+	r, err := newReplayer(filename)
+	if err != nil {
+		return err
+	}
+
+	app := newApp(nil, c)
+	app.replay = r
+
+	err = app.setupReplay()
+	if err != nil {
+		return err
+	}
+
+	return mainLoop(context.Background(), app)
+}
+
+// setupReplay performs initial application setup for replaying recorded stats.
+func (app *app) setupReplay() error {
+	// Recorded stats could be produced by any Postgres version, learn number of columns from recorded stats.
+	for name, v := range app.config.views {
+		v.Ncols = 0
+		app.config.views[name] = v
+	}
+
+	// Set default view.
+	app.config.view = app.config.views["activity"]
+
+	app.postgresProps = stat.PostgresProperties{ExtPGSSAvail: true}
+	app.uiExit = make(chan int)
+
 	return nil
 }
 
@@ -80,7 +167,9 @@ func (app *app) quit() func(g *gocui.Gui, _ *gocui.View) error {
 	return func(g *gocui.Gui, _ *gocui.View) error {
 		close(app.uiExit)
 		g.Close()
-		app.db.Close()
+		if app.db != nil {
+			app.db.Close()
+		}
 		return gocui.ErrQuit
 	}
 }
@@ -69,30 +69,31 @@ func mainLoop(ctx context.Context, app *app) error {
 }
 
 func doWork(ctx context.Context, app *app) {
+	if app.replay != nil {
+		doReplay(ctx, app)
+		return
+	}
+
 	var wg sync.WaitGroup
 	statCh := make(chan stat.Stat)
 
 	wg.Add(1)
 	go func() {
-		collectStat(ctx, app.db, statCh, app.config.viewCh)
+		collectStat(ctx, app.db, statCh, app.config.viewCh, app.config.refreshCh, app.config.refresh)
 		close(statCh)
 		wg.Done()
 	}()
 
-	// Send default view and default refresh interval to stats collector goroutine.
-	app.config.view.Refresh = time.Second
+	// Send default view to stats collector goroutine.
 	app.config.viewCh <- app.config.view
 
-	// Reset refresh interval, it should not be saved as per-view setting.
-	app.config.view.Refresh = 0
-
 	for {
 		select {
 		case <-app.uiExit:
 			// used for exit from UI (not the program) in case when need to open $PAGER or $EDITOR programs.
 			return
 		case s := <-statCh:
-			printStat(app, s, app.postgresProps)
+			printStat(app, s, time.Now())
 		case <-ctx.Done():
 			close(statCh)
 			wg.Wait()